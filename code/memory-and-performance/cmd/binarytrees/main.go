@@ -42,20 +42,11 @@ package main
 import (
 	"flag"
 	"fmt"
-	"github.com/arl/statsviz"
 	"log"
-	"net"
-	"net/http"
-	_ "net/http/pprof"
-	"os"
-	"os/signal"
-	"path/filepath"
-	"runtime"
-	"runtime/pprof"
 	"strconv"
 	"sync"
-	"syscall"
-	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/harness"
 )
 
 type Tree struct {
@@ -158,59 +149,8 @@ func Run(maxDepth int) {
 	}
 }
 
-func getExecutableName() string {
-	executable, err := os.Executable()
-	if err != nil {
-		return "unknown"
-	}
-	return filepath.Base(executable)
-}
-
-func startStatsvizServer() string {
-	listener, err := net.Listen("tcp", ":0")
-	if err != nil {
-		log.Fatal("Failed to find available port:", err)
-	}
-	p, ok := listener.Addr().(*net.TCPAddr)
-	if !ok {
-		log.Fatal("Failed to find available port")
-	}
-	port := p.Port
-	_ = listener.Close()
-
-	err = statsviz.Register(http.DefaultServeMux)
-	if err != nil {
-		log.Fatal("Failed to register statsviz:", err)
-	}
-
-	go func() {
-		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
-	}()
-
-	return fmt.Sprintf("http://localhost:%d/debug/statsviz/", port)
-}
-
 func main() {
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-
-	execName := getExecutableName()
-
-	// Start statsviz HTTP server
-	statsvizURL := startStatsvizServer()
-	fmt.Printf("statsviz server: %s\n", statsvizURL)
-
-	// CPU profiling
-	cpuFile, err := os.Create(filepath.Join("traces", fmt.Sprintf("%s_cpu.pprof", execName)))
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer cpuFile.Close()
-
-	if err := pprof.StartCPUProfile(cpuFile); err != nil {
-		log.Fatal(err)
-	}
-	defer pprof.StopCPUProfile()
+	opts := harness.RegisterFlags(flag.CommandLine)
 
 	// Parse command line arguments
 	n := 0
@@ -219,25 +159,21 @@ func main() {
 		n, _ = strconv.Atoi(flag.Arg(0))
 	}
 
-	fmt.Println("n:", n)
-	start := time.Now()
-	Run(n)
-	elapsed := time.Since(start)
-	fmt.Println("elapsed:", elapsed)
-
-	// Memory profiling
-	runtime.GC()
-	memFile, err := os.Create(filepath.Join("traces", fmt.Sprintf("%s_mem.pprof", execName)))
+	h, err := harness.New(opts, "")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer memFile.Close()
 
-	if err := pprof.WriteHeapProfile(memFile); err != nil {
+	fmt.Println("n:", n)
+	elapsed, err := h.Measure(func() {
+		Run(n)
+	})
+	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Println("elapsed:", elapsed)
 
-	fmt.Printf("Generated: %s_cpu.pprof, %s_mem.pprof\n", execName, execName)
-	fmt.Println("Press Ctrl+C to stop the server")
-	<-stop
+	if err := h.Close(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/runner"
+	"github.com/Elvis339/go_gc_eval/internal/stats"
+)
+
+// metric extracts one number from a run.
+type metric struct {
+	name   string
+	unit   string
+	scale  float64 // divide raw values by scale before printing
+	sample func(r *runner.Result) float64
+}
+
+var metrics = []metric{
+	{"wall time", "s", 1e9, func(r *runner.Result) float64 { return float64(r.Wall) }},
+	{"gc cycles", "", 1, func(r *runner.Result) float64 { return float64(len(r.GC)) }},
+	{"stw pause", "ms", 1e6, func(r *runner.Result) float64 { return float64(r.GCSummary().TotalSTW) }},
+	{"gc cpu", "ms", 1e6, func(r *runner.Result) float64 { return float64(r.GCSummary().TotalCPU) }},
+	{"peak heap", "MB", 1, func(r *runner.Result) float64 { return r.GCSummary().PeakHeap }},
+	{"peak rss", "MB", 1 << 20, func(r *runner.Result) float64 { return float64(r.MaxRSS) }},
+}
+
+// go run ./cmd/gccompare -base bin/graph -exp bin/graphx -n 10 -- -v compact -s 2000000
+func main() {
+	base := flag.String("base", "", "Baseline binary, e.g. bin/graph")
+	exp := flag.String("exp", "", "Experimental binary, e.g. bin/graphx")
+	runs := flag.Int("n", 10, "Number of measured runs per binary")
+	warmup := flag.Int("warmup", 1, "Number of discarded runs per binary before measuring")
+	timeout := flag.Duration("timeout", 10*time.Minute, "Timeout for a single run")
+	confidence := flag.Float64("confidence", 0.95, "Confidence level of the median intervals")
+	alpha := flag.Float64("alpha", 0.05, "Significance level of the Mann-Whitney U test")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -base <bin> -exp <bin> [flags] [-- args...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *base == "" || *exp == "" || *runs < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	specs := [2]runner.Spec{
+		{Path: *base, Args: args, Timeout: *timeout, GCTrace: true},
+		{Path: *exp, Args: args, Timeout: *timeout, GCTrace: true},
+	}
+
+	ctx := context.Background()
+	for i := 0; i < *warmup; i++ {
+		for _, spec := range specs {
+			if _, err := runner.Run(ctx, spec); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// Interleave the binaries and flip the order every round (ABBA) so
+	// slow drift of the machine, e.g. thermal throttling, hits both equally.
+	var results [2][]*runner.Result
+	for i := 0; i < *runs; i++ {
+		order := []int{0, 1}
+		if i%2 == 1 {
+			order = []int{1, 0}
+		}
+		for _, which := range order {
+			res, err := runner.Run(ctx, specs[which])
+			if err != nil {
+				log.Fatal(err)
+			}
+			results[which] = append(results[which], res)
+			fmt.Fprintf(os.Stderr, "run %d/%d %s: %s, %d GCs\n", i+1, *runs, specs[which].Path, res.Wall, len(res.GC))
+		}
+	}
+
+	fmt.Printf("base: %s\nexp:  %s\nargs: %s\nruns: %d each, %.0f%% intervals, alpha=%g\n\n",
+		*base, *exp, strings.Join(args, " "), *runs, *confidence*100, *alpha)
+	report(os.Stdout, results, *confidence, *alpha)
+}
+
+func report(w io.Writer, results [2][]*runner.Result, confidence, alpha float64) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "metric\tbase\texp\tdelta\tp-value\t")
+
+	for _, m := range metrics {
+		var samples [2]stats.Sample
+		for i := range results {
+			for _, r := range results[i] {
+				samples[i] = append(samples[i], m.sample(r)/m.scale)
+			}
+		}
+
+		name := m.name
+		if m.unit != "" {
+			name += " (" + m.unit + ")"
+		}
+
+		p := stats.MannWhitneyU(samples[0], samples[1])
+		delta := "~"
+		if p < alpha {
+			delta = percent(samples[0].Median(), samples[1].Median())
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.3f\t\n",
+			name, interval(samples[0], confidence), interval(samples[1], confidence), delta, p)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\n~ means the difference is not significant")
+}
+
+func interval(s stats.Sample, confidence float64) string {
+	lo, hi, _ := s.MedianCI(confidence)
+	return fmt.Sprintf("%.4g [%.4g, %.4g]", s.Median(), lo, hi)
+}
+
+func percent(base, exp float64) string {
+	if base == 0 {
+		return "n/a"
+	}
+	d := (exp - base) / base * 100
+	if math.Abs(d) < 0.05 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%+.1f%%", d)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/matrix"
+	"github.com/Elvis339/go_gc_eval/internal/plot"
+	"github.com/Elvis339/go_gc_eval/internal/stats"
+)
+
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// point is one GOGC / GOMEMLIMIT setting of one argument set, medians over
+// the successful repetitions.
+type point struct {
+	Args     string
+	GOGC     string
+	MemLimit string
+	Runs     int
+	Wall     float64 // seconds
+	GCCPU    float64 // % of CPU time spent in GC, last gctrace line
+	PeakRSS  float64 // MB
+	Pareto   bool
+}
+
+func (p point) label() string {
+	l := "GOGC=" + p.GOGC
+	if p.MemLimit != "" {
+		l += " limit=" + p.MemLimit
+	}
+	return l
+}
+
+// make graph
+// go run ./cmd/gcsweep -bin bin/graph -args "-v ptr-chasing" -args "-v compact"
+// go run ./cmd/gcsweep -bin bin/btree -gogc 50,100,200,off -memlimit ,256MiB -- 21
+func main() {
+	bin := flag.String("bin", "", "Binary to sweep, e.g. bin/graph")
+	var argSets stringList
+	flag.Var(&argSets, "args", "Argument set, split on spaces; repeat to sweep several, each gets its own curve (default: arguments after --)")
+	gogc := flag.String("gogc", "25,50,100,200,400,800", "Comma separated GOGC values, off allowed")
+	memlimit := flag.String("memlimit", "", "Comma separated GOMEMLIMIT values, an empty entry means no limit")
+	runs := flag.Int("n", 3, "Repetitions per point")
+	timeout := flag.Duration("timeout", 10*time.Minute, "Timeout of a single run")
+	name := flag.String("name", "", "Sweep name (default: binary name)")
+	out := flag.String("out", filepath.Join("traces", "sweep"), "Results root")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -bin <binary> [flags] [-- args...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *bin == "" || *runs < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	sets := make([][]string, 0, len(argSets))
+	for _, a := range argSets {
+		sets = append(sets, strings.Fields(a))
+	}
+	if len(sets) == 0 {
+		sets = append(sets, flag.Args())
+	}
+	if *name == "" {
+		*name = filepath.Base(*bin) + "-sweep"
+	}
+
+	spec := &matrix.Spec{
+		Name:     *name,
+		Out:      *out,
+		BinDir:   filepath.Dir(*bin),
+		Binaries: []string{filepath.Base(*bin)},
+		Builds:   []string{""},
+		Args:     sets,
+		Env: map[string][]string{
+			"GOGC":       strings.Split(*gogc, ","),
+			"GOMEMLIMIT": strings.Split(*memlimit, ","),
+		},
+		Repetitions: *runs,
+		Timeout:     matrix.Duration(*timeout),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	m, err := matrix.Run(ctx, spec, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	points := collect(m)
+	markPareto(points)
+
+	csvPath := filepath.Join(m.Dir, "pareto.csv")
+	svgPath := filepath.Join(m.Dir, "pareto.svg")
+	if err := writeFile(csvPath, func(w io.Writer) error { return writeCSV(w, points) }); err != nil {
+		log.Fatal(err)
+	}
+	if err := writeFile(svgPath, func(w io.Writer) error { return chart(*name, points).SVG(w) }); err != nil {
+		log.Fatal(err)
+	}
+	printPoints(points)
+	fmt.Printf("\nGenerated:\n  %s\n  %s\n  %s/manifest.json\n", csvPath, svgPath, m.Dir)
+}
+
+func collect(m *matrix.Manifest) []point {
+	var points []point
+	for _, c := range m.Cells {
+		p := point{Args: strings.Join(c.Args, " "), GOGC: "100"}
+		for _, kv := range c.Env {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "GOGC":
+				p.GOGC = v
+			case "GOMEMLIMIT":
+				p.MemLimit = v
+			}
+		}
+
+		var wall, gc, rss stats.Sample
+		for _, r := range c.Reps {
+			if r.Error != "" {
+				continue
+			}
+			wall = append(wall, r.Wall.Seconds())
+			gc = append(gc, float64(r.GCPercent))
+			rss = append(rss, float64(r.MaxRSS)/(1<<20))
+		}
+		if len(wall) == 0 {
+			// Timeouts and OOM kills are expected at the edges of a sweep.
+			log.Printf("%s: every run failed, point skipped", c.ID)
+			continue
+		}
+		p.Runs = len(wall)
+		p.Wall, p.GCCPU, p.PeakRSS = wall.Median(), gc.Median(), rss.Median()
+		points = append(points, p)
+	}
+	return points
+}
+
+// markPareto marks, per argument set, the points no other point beats on
+// both wall time and peak RSS.
+func markPareto(points []point) {
+	bySet := make(map[string][]int)
+	for i, p := range points {
+		bySet[p.Args] = append(bySet[p.Args], i)
+	}
+	for _, idx := range bySet {
+		sort.Slice(idx, func(a, b int) bool {
+			pa, pb := points[idx[a]], points[idx[b]]
+			if pa.PeakRSS != pb.PeakRSS {
+				return pa.PeakRSS < pb.PeakRSS
+			}
+			return pa.Wall < pb.Wall
+		})
+		best := -1.0
+		for _, i := range idx {
+			if best < 0 || points[i].Wall < best {
+				points[i].Pareto = true
+				best = points[i].Wall
+			}
+		}
+	}
+}
+
+func chart(name string, points []point) *plot.LineChart {
+	c := &plot.LineChart{
+		Title:  name + ": wall time vs peak RSS (dashed: Pareto frontier)",
+		XLabel: "peak RSS (MB)",
+		YLabel: "wall time (s)",
+		Height: 480,
+	}
+	var sets []string
+	seen := make(map[string]bool)
+	for _, p := range points {
+		if !seen[p.Args] {
+			seen[p.Args] = true
+			sets = append(sets, p.Args)
+		}
+	}
+	for i, set := range sets {
+		color := plot.Palette[i%len(plot.Palette)]
+		all := plot.Series{Name: set, Color: color, Scatter: true, Annotate: true}
+		frontier := plot.Series{Name: set, Color: color, Dashed: true}
+		var front []point
+		for _, p := range points {
+			if p.Args != set {
+				continue
+			}
+			all.X = append(all.X, p.PeakRSS)
+			all.Y = append(all.Y, p.Wall)
+			all.Labels = append(all.Labels, p.label())
+			if p.Pareto {
+				front = append(front, p)
+			}
+		}
+		sort.Slice(front, func(a, b int) bool { return front[a].PeakRSS < front[b].PeakRSS })
+		for _, p := range front {
+			frontier.X = append(frontier.X, p.PeakRSS)
+			frontier.Y = append(frontier.Y, p.Wall)
+		}
+		if all.Name == "" {
+			all.Name = "(no args)"
+		}
+		c.Series = append(c.Series, all, frontier)
+	}
+	return c
+}
+
+func printPoints(points []point) {
+	fmt.Printf("\n%-24s %-8s %-10s %10s %8s %12s %s\n", "args", "GOGC", "GOMEMLIMIT", "wall (s)", "GC %", "peak RSS MB", "pareto")
+	for _, p := range points {
+		limit := p.MemLimit
+		if limit == "" {
+			limit = "-"
+		}
+		mark := ""
+		if p.Pareto {
+			mark = "*"
+		}
+		fmt.Printf("%-24s %-8s %-10s %10.3f %8.0f %12.1f %s\n", p.Args, p.GOGC, limit, p.Wall, p.GCCPU, p.PeakRSS, mark)
+	}
+}
+
+func writeCSV(w io.Writer, points []point) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"args", "gogc", "gomemlimit", "runs", "wall_s", "gc_cpu_percent", "peak_rss_mb", "pareto"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, p := range points {
+		_ = cw.Write([]string{p.Args, p.GOGC, p.MemLimit, strconv.Itoa(p.Runs), f(p.Wall), f(p.GCCPU), f(p.PeakRSS), strconv.FormatBool(p.Pareto)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/gctrace"
+)
+
+type trace struct {
+	Name    string           `json:"name"`
+	Path    string           `json:"path"`
+	Summary gctrace.Summary  `json:"summary"`
+	Records []gctrace.Record `json:"records"`
+}
+
+// go run ./cmd/gctrace traces/graph.gctrace traces/graphx.gctrace
+// go run ./cmd/gctrace -csv traces/graph.csv -json traces/graph.json traces/graph.gctrace
+func main() {
+	csvPath := flag.String("csv", "", "Write every parsed record to this CSV file")
+	jsonPath := flag.String("json", "", "Write summaries and records to this JSON file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <file.gctrace>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	traces := make([]trace, 0, flag.NArg())
+	for _, path := range flag.Args() {
+		records, err := gctrace.ParseFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		traces = append(traces, trace{
+			Name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			Path:    path,
+			Summary: gctrace.Summarize(records),
+			Records: records,
+		})
+	}
+
+	printTable(os.Stdout, traces)
+
+	if *csvPath != "" {
+		if err := writeFile(*csvPath, func(w io.Writer) error { return writeCSV(w, traces) }); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeFile(*jsonPath, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(traces)
+		}); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+type metric struct {
+	name   string
+	value  func(s gctrace.Summary) float64
+	format func(s gctrace.Summary) string
+}
+
+var metrics = []metric{
+	{
+		name:   "Worst Marking Time",
+		value:  func(s gctrace.Summary) float64 { return float64(s.WorstMark) },
+		format: func(s gctrace.Summary) string { return fmt.Sprintf("%s (GC %d)", ms(s.WorstMark), s.WorstMarkCycle) },
+	},
+	{
+		name:  "Highest GC %",
+		value: func(s gctrace.Summary) float64 { return float64(s.MaxGCPercent) },
+		format: func(s gctrace.Summary) string {
+			return fmt.Sprintf("%d%% (GC %d)", s.MaxGCPercent, s.MaxGCPercentCycle)
+		},
+	},
+	{
+		name:   "Peak CPU Overhead",
+		value:  func(s gctrace.Summary) float64 { return float64(s.PeakCPU) },
+		format: func(s gctrace.Summary) string { return fmt.Sprintf("%s (GC %d)", ms(s.PeakCPU), s.PeakCPUCycle) },
+	},
+	{
+		name:  "Max Heap Growth",
+		value: func(s gctrace.Summary) float64 { return s.PeakHeap },
+		format: func(s gctrace.Summary) string {
+			return fmt.Sprintf("%gMB (%g→%g)", s.PeakHeap, s.HeapStart, s.PeakHeap)
+		},
+	},
+	{
+		name:   "Total STW Pause",
+		value:  func(s gctrace.Summary) float64 { return float64(s.TotalSTW) },
+		format: func(s gctrace.Summary) string { return ms(s.TotalSTW) },
+	},
+	{
+		name:   "Total GC CPU",
+		value:  func(s gctrace.Summary) float64 { return float64(s.TotalCPU) },
+		format: func(s gctrace.Summary) string { return ms(s.TotalCPU) },
+	},
+	{
+		name:   "GC Cycles",
+		value:  func(s gctrace.Summary) float64 { return float64(s.Cycles) },
+		format: func(s gctrace.Summary) string { return fmt.Sprintf("%d (%d forced)", s.Cycles, s.Forced) },
+	},
+}
+
+// printTable writes a markdown table with one column per trace. With exactly
+// two traces a Difference column compares the second against the first.
+func printTable(w io.Writer, traces []trace) {
+	header := []string{"Metric"}
+	for _, t := range traces {
+		header = append(header, t.Name)
+	}
+	diff := len(traces) == 2
+	if diff {
+		header = append(header, "Difference")
+	}
+
+	fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat("--------|", len(header)))
+	for _, m := range metrics {
+		row := []string{"**" + m.name + "**"}
+		for _, t := range traces {
+			row = append(row, m.format(t.Summary))
+		}
+		if diff {
+			row = append(row, difference(m.value(traces[0].Summary), m.value(traces[1].Summary)))
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+	}
+}
+
+func difference(base, other float64) string {
+	if base == 0 {
+		return "-"
+	}
+	change := (other - base) / base * 100
+	switch {
+	case change < 0:
+		return fmt.Sprintf("**%.0f%% lower**", -change)
+	case change > 0:
+		return fmt.Sprintf("**%.0f%% higher**", change)
+	}
+	return "same"
+}
+
+func ms(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'g', 4, 64) + "ms"
+}
+
+func writeCSV(w io.Writer, traces []trace) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"trace", "cycle", "at_s", "gc_percent",
+		"clock_sweep_term_ms", "clock_mark_ms", "clock_mark_term_ms",
+		"cpu_sweep_term_ms", "cpu_assist_ms", "cpu_background_ms", "cpu_idle_ms", "cpu_mark_term_ms",
+		"heap_before_mb", "heap_after_mb", "heap_live_mb", "heap_goal_mb", "stacks_mb", "globals_mb",
+		"procs", "forced",
+	})
+
+	msf := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+	}
+	mbf := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, t := range traces {
+		for _, r := range t.Records {
+			_ = cw.Write([]string{
+				t.Name, strconv.Itoa(r.Cycle), strconv.FormatFloat(r.At.Seconds(), 'f', -1, 64), strconv.Itoa(r.GCPercent),
+				msf(r.Clock.SweepTerm), msf(r.Clock.Mark), msf(r.Clock.MarkTerm),
+				msf(r.CPU.SweepTerm), msf(r.CPU.Assist), msf(r.CPU.Background), msf(r.CPU.Idle), msf(r.CPU.MarkTerm),
+				mbf(r.HeapBefore), mbf(r.HeapAfter), mbf(r.HeapLive), mbf(r.HeapGoal), mbf(r.Stacks), mbf(r.Globals),
+				strconv.Itoa(r.Procs), strconv.FormatBool(r.Forced),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+)
+
+// Every algorithm below exists twice: over graphNode, following pointers and
+// keeping per-node state in maps keyed by id like bfs, and over compactGraph,
+// indexing flat slices like compactGraph.bfs. Both versions of an algorithm
+// return the same result for the same edge list.
+
+// edgeWeight is the weight of the edge from -> to used by the shortest path
+// algorithms when the input has none, as for generated graphs: 1..100. It is
+// a hash of the endpoints' ids, not their slots, so every layout and every
+// reordering sees the same weights without storing them.
+func edgeWeight(from, to int) float64 {
+	h := uint64(from)*0x9e3779b97f4a7c15 ^ uint64(to)*0xc2b2ae3d27d4eb4f
+	h ^= h >> 31
+	h *= 0x94d049bb133111eb
+	h ^= h >> 29
+	return float64(h%100 + 1)
+}
+
+// weightTable holds the weights read from an input file in CSR order: the
+// j-th out-edge of the node with id id weighs weights[offsets[id]+j].
+// graphNode and compactGraph keep every node's edges in input order, also
+// after reordering, so their Dijkstra looks weights up here instead of every
+// node carrying a weight slice the other algorithms would pay for. A nil
+// table means hashed weights.
+type weightTable struct {
+	offsets []int32
+	weights []float64
+}
+
+// newWeightTable returns the weight table of e, nil when e has no weights.
+func newWeightTable(e *graphdata.Edges) (*weightTable, error) {
+	if e.Weights == nil {
+		return nil, nil
+	}
+	offsets, _, err := e.CSR()
+	if err != nil {
+		return nil, err
+	}
+	return &weightTable{offsets: offsets, weights: e.CSRWeights(offsets)}, nil
+}
+
+// weight returns the weight of the j-th out-edge of node id, which goes to
+// node to.
+func (t *weightTable) weight(id, j, to int) float64 {
+	if t == nil {
+		return edgeWeight(id, to)
+	}
+	return t.weights[int(t.offsets[id])+j]
+}
+
+// checkWeights rejects weights Dijkstra cannot use.
+func checkWeights(weights []float64) error {
+	for i, w := range weights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			return fmt.Errorf("edge %d weighs %g, shortest paths need finite non-negative weights", i, w)
+		}
+	}
+	return nil
+}
+
+// dfs counts the nodes reachable from root with an explicit stack.
+func dfs(root *graphNode) int {
+	visited := make(map[int]bool)
+	stack := []*graphNode{root}
+	count := 0
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if visited[current.id] {
+			continue
+		}
+		visited[current.id] = true
+		count++
+
+		for _, neighbor := range current.neighbors {
+			if !visited[neighbor.id] {
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+
+	return count
+}
+
+func (g *compactGraph) dfs(startID int) int {
+	visited := make([]bool, len(g.nodes))
+	stack := []int{startID}
+	count := 0
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		count++
+
+		for _, neighborID := range g.nodes[current].neighbors {
+			if !visited[neighborID] {
+				stack = append(stack, neighborID)
+			}
+		}
+	}
+
+	return count
+}
+
+// ufNode is a union-find set linked by pointers.
+type ufNode struct {
+	parent *ufNode
+	size   int
+}
+
+func (n *ufNode) find() *ufNode {
+	for n.parent != n {
+		n.parent = n.parent.parent // path halving
+		n = n.parent
+	}
+	return n
+}
+
+// components counts the weakly connected components with a union-find over
+// one heap allocated set per node.
+func components(nodes []*graphNode) int {
+	sets := make(map[int]*ufNode, len(nodes))
+	for _, n := range nodes {
+		s := &ufNode{size: 1}
+		s.parent = s
+		sets[n.id] = s
+	}
+
+	count := len(nodes)
+	for _, n := range nodes {
+		for _, neighbor := range n.neighbors {
+			a, b := sets[n.id].find(), sets[neighbor.id].find()
+			if a == b {
+				continue
+			}
+			if a.size < b.size {
+				a, b = b, a
+			}
+			b.parent = a
+			a.size += b.size
+			count--
+		}
+	}
+	return count
+}
+
+func (g *compactGraph) components() int {
+	parent := make([]int32, len(g.nodes))
+	size := make([]int32, len(g.nodes))
+	for i := range parent {
+		parent[i], size[i] = int32(i), 1
+	}
+	find := func(i int32) int32 {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	count := len(g.nodes)
+	for i := range g.nodes {
+		for _, neighborID := range g.nodes[i].neighbors {
+			a, b := find(int32(i)), find(int32(neighborID))
+			if a == b {
+				continue
+			}
+			if size[a] < size[b] {
+				a, b = b, a
+			}
+			parent[b] = a
+			size[a] += size[b]
+			count--
+		}
+	}
+	return count
+}
+
+const damping = 0.85
+
+// pageRank runs iters power iterations. Rank of nodes without out-edges is
+// spread evenly over all nodes.
+func pageRank(nodes []*graphNode, iters int) map[int]float64 {
+	n := float64(len(nodes))
+	rank := make(map[int]float64, len(nodes))
+	for _, node := range nodes {
+		rank[node.id] = 1 / n
+	}
+
+	for it := 0; it < iters; it++ {
+		next := make(map[int]float64, len(nodes))
+		dangling := 0.0
+		for _, node := range nodes {
+			if len(node.neighbors) == 0 {
+				dangling += rank[node.id]
+				continue
+			}
+			share := rank[node.id] / float64(len(node.neighbors))
+			for _, neighbor := range node.neighbors {
+				next[neighbor.id] += share
+			}
+		}
+		base := (1-damping)/n + damping*dangling/n
+		for _, node := range nodes {
+			next[node.id] = base + damping*next[node.id]
+		}
+		rank = next
+	}
+	return rank
+}
+
+func (g *compactGraph) pageRank(iters int) []float64 {
+	n := float64(len(g.nodes))
+	rank := make([]float64, len(g.nodes))
+	next := make([]float64, len(g.nodes))
+	for i := range rank {
+		rank[i] = 1 / n
+	}
+
+	for it := 0; it < iters; it++ {
+		clear(next)
+		dangling := 0.0
+		for i := range g.nodes {
+			neighbors := g.nodes[i].neighbors
+			if len(neighbors) == 0 {
+				dangling += rank[i]
+				continue
+			}
+			share := rank[i] / float64(len(neighbors))
+			for _, neighborID := range neighbors {
+				next[neighborID] += share
+			}
+		}
+		base := (1-damping)/n + damping*dangling/n
+		for i := range next {
+			next[i] = base + damping*next[i]
+		}
+		rank, next = next, rank
+	}
+	return rank
+}
+
+// ptrItem is a queued node of the pointer Dijkstra.
+type ptrItem struct {
+	node *graphNode
+	dist float64
+}
+
+type ptrQueue []ptrItem
+
+func (q ptrQueue) Len() int           { return len(q) }
+func (q ptrQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q ptrQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *ptrQueue) Push(x any)        { *q = append(*q, x.(ptrItem)) }
+func (q *ptrQueue) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// shortestPaths runs Dijkstra from root with the weights of w and returns
+// the number of reachable nodes and the sum of their distances.
+func shortestPaths(root *graphNode, w *weightTable) (reached int, total float64) {
+	dist := map[int]float64{root.id: 0}
+	done := make(map[int]bool)
+	q := &ptrQueue{{root, 0}}
+
+	for q.Len() > 0 {
+		it := heap.Pop(q).(ptrItem)
+		if done[it.node.id] {
+			continue
+		}
+		done[it.node.id] = true
+		reached++
+		total += it.dist
+
+		for j, neighbor := range it.node.neighbors {
+			d := it.dist + w.weight(it.node.id, j, neighbor.id)
+			if old, ok := dist[neighbor.id]; !ok || d < old {
+				dist[neighbor.id] = d
+				heap.Push(q, ptrItem{neighbor, d})
+			}
+		}
+	}
+	return reached, total
+}
+
+// indexItem is a queued node of the index Dijkstra, also used by the flat
+// layouts.
+type indexItem struct {
+	id   int32
+	dist float64
+}
+
+type indexQueue []indexItem
+
+func (q indexQueue) Len() int           { return len(q) }
+func (q indexQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q indexQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *indexQueue) Push(x any)        { *q = append(*q, x.(indexItem)) }
+func (q *indexQueue) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+func (g *compactGraph) shortestPaths(startID int, w *weightTable) (reached int, total float64) {
+	dist := make([]float64, len(g.nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	done := make([]bool, len(g.nodes))
+	dist[startID] = 0
+	q := &indexQueue{{int32(startID), 0}}
+
+	for q.Len() > 0 {
+		it := heap.Pop(q).(indexItem)
+		if done[it.id] {
+			continue
+		}
+		done[it.id] = true
+		reached++
+		total += it.dist
+
+		for j, neighborID := range g.nodes[it.id].neighbors {
+			d := it.dist + w.weight(g.nodes[it.id].id, j, g.nodes[neighborID].id)
+			if d < dist[neighborID] {
+				dist[neighborID] = d
+				heap.Push(q, indexItem{int32(neighborID), d})
+			}
+		}
+	}
+	return reached, total
+}
+
+// flatShortestPaths is the Dijkstra of the flat layouts, which keep no node
+// ids: node i is id i. expand calls relax for every out-edge of id with its
+// weight.
+func flatShortestPaths(n int, startID int32, expand func(id int32, relax func(to int32, w float64))) (reached int, total float64) {
+	dist := make([]float64, n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	done := newBitset(n)
+	dist[startID] = 0
+	q := &indexQueue{{startID, 0}}
+
+	var from float64
+	relax := func(to int32, w float64) {
+		if d := from + w; d < dist[to] {
+			dist[to] = d
+			heap.Push(q, indexItem{to, d})
+		}
+	}
+	for q.Len() > 0 {
+		it := heap.Pop(q).(indexItem)
+		if done.has(it.id) {
+			continue
+		}
+		done.set(it.id)
+		reached++
+		total += it.dist
+
+		from = it.dist
+		expand(it.id, relax)
+	}
+	return reached, total
+}
+
+// bytes returns the memory holding the table, 0 for hashed weights.
+func (t *weightTable) bytes() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.offsets)*4 + len(t.weights)*8
+}
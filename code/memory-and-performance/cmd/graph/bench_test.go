@@ -1,14 +1,69 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"runtime"
+	"sync"
 	"testing"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+	"github.com/Elvis339/go_gc_eval/internal/graphgen"
 )
 
 // go test -bench=. -count=3
 
+// benchEdges is the graph every benchmark traverses, the same one
+// `graph -s 2000000` builds with the default seed. It is generated once per
+// test binary and kept as a snapshot until the run ends.
+func benchEdges(b *testing.B) *graphdata.Edges {
+	path := benchSnapshot(b)
+	s, err := graphdata.ReadSnapshot(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return s.EdgeList()
+}
+
+// The benchmark snapshot lives in a directory TestMain removes. It is not
+// shared between runs: a file left by an older graphgen or snapshot format
+// would be benchmarked as if the current code had produced it.
+var (
+	snapshotOnce sync.Once
+	snapshotDir  string
+	snapshotPath string
+	snapshotErr  error
+)
+
+func benchSnapshot(b *testing.B) string {
+	snapshotOnce.Do(func() {
+		var e *graphdata.Edges
+		e, snapshotErr = graphgen.Generate(graphgen.Config{Topology: graphgen.Uniform, Nodes: 2_000_000, Seed: 1})
+		if snapshotErr != nil {
+			return
+		}
+		if snapshotDir, snapshotErr = os.MkdirTemp("", "go_gc_eval-graph-"); snapshotErr != nil {
+			return
+		}
+		snapshotPath = filepath.Join(snapshotDir, "uniform-2000000-1.snap")
+		snapshotErr = graphdata.WriteSnapshot(snapshotPath, e)
+	})
+	if snapshotErr != nil {
+		b.Fatal(snapshotErr)
+	}
+	return snapshotPath
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if snapshotDir != "" {
+		os.RemoveAll(snapshotDir)
+	}
+	os.Exit(code)
+}
+
 func BenchmarkGraph(b *testing.B) {
-	graph := createGraph(2_000_000)
+	graph := createGraph(benchEdges(b))
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -17,10 +72,181 @@ func BenchmarkGraph(b *testing.B) {
 }
 
 func BenchmarkCompact(b *testing.B) {
-	graph := createCompactGraph(2_000_000)
+	graph := createCompactGraph(benchEdges(b))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		runtime.KeepAlive(graph.bfs(0))
+	}
+}
+
+func BenchmarkCSR(b *testing.B) {
+	graph, err := createCSRGraph(benchEdges(b))
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
 		runtime.KeepAlive(graph.bfs(0))
 	}
 }
+
+// go test -bench='CSR$|Compressed'
+// Decoding cost against memory traffic; B/edge is the adjacency size.
+
+func BenchmarkCompressed(b *testing.B) {
+	e := benchEdges(b)
+	for _, enc := range []string{varintEncoding, int32Encoding} {
+		graph, err := createCompressedGraph(e, enc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(enc, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				runtime.KeepAlive(graph.bfs(0))
+			}
+			b.ReportMetric(float64(graph.bytes())/float64(graph.edges), "B/edge")
+		})
+	}
+}
+
+// go test -bench=Snapshot
+// CSR traversal of the snapshot read into the heap and mapped in place.
+
+func BenchmarkSnapshot(b *testing.B) {
+	path := benchSnapshot(b)
+	for _, name := range []string{"read", "mmap"} {
+		b.Run(name, func(b *testing.B) {
+			load := graphdata.ReadSnapshot
+			if name == "mmap" {
+				load = graphdata.MapSnapshot
+			}
+			s, err := load(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer s.Close()
+			graph := &csrGraph{offsets: s.Offsets, edges: s.Edges}
+			benchGC(b, func() int { return graph.bfs(0) })
+		})
+	}
+}
+
+// go test -bench=Reordered
+// The same BFS over the compact graph after each reordering, the difference
+// to BenchmarkCompact is layout alone.
+
+func BenchmarkReordered(b *testing.B) {
+	graph := createCompactGraph(benchEdges(b))
+	for _, name := range []string{"bfs", "degree", "rcm"} {
+		perm := reorderings[name](graph)
+		reordered, start := graph.reorder(perm), perm[0]
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				runtime.KeepAlive(reordered.bfs(start))
+			}
+		})
+	}
+}
+
+// go test -bench=Strategies -count=3
+// Every visited set crossed with every queue, over both layouts. gc/op is
+// the number of GC cycles per traversal.
+
+func BenchmarkStrategies(b *testing.B) {
+	e := benchEdges(b)
+	nodes, compact := createGraphNodes(e), createCompactGraph(e)
+
+	for _, vs := range visitedSets {
+		for _, qs := range queues {
+			b.Run("ptr-chasing/"+vs+"/"+qs, func(b *testing.B) {
+				set, _ := newNodeVisitedSet(vs, nodes)
+				q, _ := newQueue[*graphNode](qs)
+				benchGC(b, func() int { return bfsWith(nodes[0], set, q) })
+			})
+			if vs == "node" {
+				continue
+			}
+			b.Run("compact/"+vs+"/"+qs, func(b *testing.B) {
+				set, _ := newVisitedSet(vs, len(compact.nodes))
+				q, _ := newQueue[int](qs)
+				benchGC(b, func() int { return compact.bfsWith(0, set, q) })
+			})
+		}
+	}
+}
+
+// benchGC runs fn b.N times reporting allocations and GC cycles per op.
+func benchGC(b *testing.B, fn func() int) {
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.KeepAlive(fn())
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+}
+
+// go test -bench='Compact(Parallel|DirOpt)' -cpu 1,2,4,8
+// Both use one worker per P.
+
+func BenchmarkCompactParallel(b *testing.B) {
+	graph := createCompactGraph(benchEdges(b))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		runtime.KeepAlive(graph.parallelBFS(0, runtime.GOMAXPROCS(0)))
+	}
+}
+
+func BenchmarkCompactDirOpt(b *testing.B) {
+	graph := createCompactGraph(benchEdges(b))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		runtime.KeepAlive(graph.dirOptBFS(0, runtime.GOMAXPROCS(0)))
+	}
+}
+
+// go test -bench=Algorithms -count=3
+
+func BenchmarkAlgorithms(b *testing.B) {
+	e := benchEdges(b)
+	nodes, compact := createGraphNodes(e), createCompactGraph(e)
+	csr, err := createCSRGraph(e)
+	if err != nil {
+		b.Fatal(err)
+	}
+	varint, err := createCompressedGraph(e, varintEncoding)
+	if err != nil {
+		b.Fatal(err)
+	}
+	const iters = 5
+
+	benchmarks := []struct {
+		name string
+		fn   func() any
+	}{
+		{"dfs/ptr-chasing", func() any { return dfs(nodes[0]) }},
+		{"dfs/compact", func() any { return compact.dfs(0) }},
+		{"cc/ptr-chasing", func() any { return components(nodes) }},
+		{"cc/compact", func() any { return compact.components() }},
+		{"pagerank/ptr-chasing", func() any { return pageRank(nodes, iters) }},
+		{"pagerank/compact", func() any { return compact.pageRank(iters) }},
+		{"sssp/ptr-chasing", func() any { r, _ := shortestPaths(nodes[0], nil); return r }},
+		{"sssp/compact", func() any { r, _ := compact.shortestPaths(0, nil); return r }},
+		{"sssp/csr", func() any { r, _ := csr.shortestPaths(0); return r }},
+		{"sssp/varint", func() any { r, _ := varint.shortestPaths(0); return r }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				runtime.KeepAlive(bm.fn())
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+	"github.com/Elvis339/go_gc_eval/internal/sampler"
+)
+
+// Churn keeps a graph alive and mutating instead of building it once and
+// traversing it once: every step removes a batch of nodes with their edges,
+// adds as many new ones, rewires batch×degree random edges and runs a BFS
+// every bfsEvery steps. The heap reaches a steady state of allocation and
+// death, which is where fragmentation and the scavenger show up.
+
+const (
+	bfsEvery     = 10
+	reportPeriod = time.Second
+)
+
+// churner is a graph layout under churn. Node 0 is never removed and is
+// topped up to degree out-edges after every step, it is the BFS root and
+// must not end up isolated.
+type churner interface {
+	step(r *rand.Rand, batch int)
+	bfs() int
+	size() (nodes, edges int)
+}
+
+// churnResult summarises a churn run.
+type churnResult struct {
+	steps, bfsRuns, replaced int
+	visited                  int // nodes reached by the last BFS
+	nodes, edges             int
+}
+
+func (r churnResult) String() string {
+	return fmt.Sprintf("Churn: %d steps, %d nodes replaced, %d BFS runs, last visited %d nodes; final graph %d nodes, %d edges",
+		r.steps, r.replaced, r.bfsRuns, r.visited, r.nodes, r.edges)
+}
+
+// newChurner builds the layout v from e. The average out-degree of e is the
+// degree of the nodes added later.
+func newChurner(v string, e *graphdata.Edges) churner {
+	degree := max(1, e.Len()/max(1, e.Nodes))
+	if v == "compact" {
+		return &compactChurn{g: createCompactGraph(e), degree: degree}
+	}
+	nodes := createGraphNodes(e)
+	return &ptrChurn{nodes: nodes, nextID: len(nodes), degree: degree}
+}
+
+// churn runs c for d, printing heap in-use, RSS, GC cycles and pauses every
+// reportPeriod.
+func churn(c churner, d time.Duration, seed uint64) churnResult {
+	r := rand.New(rand.NewPCG(seed, 0x9e3779b97f4a7c15))
+	nodes, _ := c.size()
+	batch := max(1, nodes/1000)
+
+	fmt.Printf("%8s %12s %12s %10s %6s %12s %12s\n", "elapsed", "heap in-use", "heap idle", "rss", "gcs", "pause total", "pause max")
+	var res churnResult
+	start := time.Now()
+	rep := newChurnReporter()
+	next := start.Add(reportPeriod)
+	for time.Since(start) < d {
+		c.step(r, batch)
+		res.steps++
+		res.replaced += batch
+		if res.steps%bfsEvery == 0 {
+			res.visited = c.bfs()
+			res.bfsRuns++
+		}
+		if now := time.Now(); now.After(next) {
+			rep.report(now.Sub(start))
+			next = next.Add(reportPeriod)
+		}
+	}
+	rep.report(time.Since(start))
+	res.nodes, res.edges = c.size()
+	return res
+}
+
+// churnReporter prints the change in GC statistics since its last report.
+type churnReporter struct {
+	numGC uint32
+	pause uint64
+}
+
+func newChurnReporter() *churnReporter {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return &churnReporter{numGC: ms.NumGC, pause: ms.PauseTotalNs}
+}
+
+func (c *churnReporter) report(elapsed time.Duration) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var maxPause uint64
+	for gc := c.numGC + 1; gc <= ms.NumGC && ms.NumGC-gc < uint32(len(ms.PauseNs)); gc++ {
+		maxPause = max(maxPause, ms.PauseNs[(gc+255)%256])
+	}
+	rss := "-"
+	if v, ok := sampler.ReadRSS(); ok {
+		rss = fmt.Sprintf("%.1fMB", float64(v)/(1<<20))
+	}
+	fmt.Printf("%8s %12s %12s %10s %6d %12s %12s\n",
+		elapsed.Round(time.Second),
+		fmt.Sprintf("%.1fMB", float64(ms.HeapInuse)/(1<<20)),
+		fmt.Sprintf("%.1fMB", float64(ms.HeapIdle-ms.HeapReleased)/(1<<20)),
+		rss,
+		ms.NumGC-c.numGC,
+		time.Duration(ms.PauseTotalNs-c.pause),
+		time.Duration(maxPause))
+	c.numGC, c.pause = ms.NumGC, ms.PauseTotalNs
+}
+
+// ptrChurn churns the pointer graph. Removed nodes get id -1 until the sweep
+// has dropped every edge to them, then nothing references them.
+type ptrChurn struct {
+	nodes  []*graphNode
+	nextID int
+	degree int
+}
+
+func (c *ptrChurn) random(r *rand.Rand) *graphNode {
+	return c.nodes[r.IntN(len(c.nodes))]
+}
+
+func (c *ptrChurn) step(r *rand.Rand, batch int) {
+	for k := 0; k < batch && len(c.nodes) > 1; k++ {
+		i := 1 + r.IntN(len(c.nodes)-1)
+		c.nodes[i].id, c.nodes[i].neighbors = -1, nil
+		last := len(c.nodes) - 1
+		c.nodes[i], c.nodes[last] = c.nodes[last], nil
+		c.nodes = c.nodes[:last]
+	}
+	for _, n := range c.nodes {
+		n.neighbors = slices.DeleteFunc(n.neighbors, func(nb *graphNode) bool { return nb.id < 0 })
+	}
+
+	for k := 0; k < batch; k++ {
+		n := &graphNode{id: c.nextID, neighbors: make([]*graphNode, 0, c.degree)}
+		c.nextID++
+		for j := 0; j < c.degree; j++ {
+			n.neighbors = append(n.neighbors, c.random(r))
+		}
+		from := c.random(r)
+		c.nodes = append(c.nodes, n)
+		from.neighbors = append(from.neighbors, n)
+	}
+
+	for k := 0; k < batch*c.degree; k++ {
+		if n := c.random(r); len(n.neighbors) > 0 {
+			j, last := r.IntN(len(n.neighbors)), len(n.neighbors)-1
+			n.neighbors[j], n.neighbors[last] = n.neighbors[last], nil
+			n.neighbors = n.neighbors[:last]
+		}
+		from := c.random(r)
+		from.neighbors = append(from.neighbors, c.random(r))
+	}
+
+	for root := c.nodes[0]; len(root.neighbors) < c.degree; {
+		root.neighbors = append(root.neighbors, c.random(r))
+	}
+}
+
+func (c *ptrChurn) bfs() int { return bfs(c.nodes[0]) }
+
+func (c *ptrChurn) size() (nodes, edges int) {
+	for _, n := range c.nodes {
+		edges += len(n.neighbors)
+	}
+	return len(c.nodes), edges
+}
+
+// compactChurn churns the compact graph. Removed slots get id -1 and go to
+// the free list, new nodes fill free slots before growing the slice.
+type compactChurn struct {
+	g      *compactGraph
+	free   []int
+	degree int
+}
+
+func (c *compactChurn) random(r *rand.Rand) int {
+	for {
+		if i := r.IntN(len(c.g.nodes)); c.g.nodes[i].id >= 0 {
+			return i
+		}
+	}
+}
+
+func (c *compactChurn) step(r *rand.Rand, batch int) {
+	nodes := c.g.nodes
+	for k := 0; k < batch && c.g.size > 1; k++ {
+		i := c.random(r)
+		if i == 0 {
+			k--
+			continue
+		}
+		nodes[i].id, nodes[i].neighbors = -1, nil
+		c.free = append(c.free, i)
+		c.g.size--
+	}
+	for i := range nodes {
+		nodes[i].neighbors = slices.DeleteFunc(nodes[i].neighbors, func(nb int) bool { return nodes[nb].id < 0 })
+	}
+
+	for k := 0; k < batch; k++ {
+		neighbors := make([]int, 0, c.degree)
+		for j := 0; j < c.degree; j++ {
+			neighbors = append(neighbors, c.random(r))
+		}
+		from := c.random(r)
+		var i int
+		if n := len(c.free); n > 0 {
+			i, c.free = c.free[n-1], c.free[:n-1]
+		} else {
+			i = len(c.g.nodes)
+			c.g.nodes = append(c.g.nodes, compactNode{})
+		}
+		c.g.nodes[i] = compactNode{id: i, neighbors: neighbors}
+		c.g.nodes[from].neighbors = append(c.g.nodes[from].neighbors, i)
+		c.g.size++
+	}
+
+	for k := 0; k < batch*c.degree; k++ {
+		if n := &c.g.nodes[c.random(r)]; len(n.neighbors) > 0 {
+			j, last := r.IntN(len(n.neighbors)), len(n.neighbors)-1
+			n.neighbors[j] = n.neighbors[last]
+			n.neighbors = n.neighbors[:last]
+		}
+		from := c.random(r)
+		c.g.nodes[from].neighbors = append(c.g.nodes[from].neighbors, c.random(r))
+	}
+
+	for root := &c.g.nodes[0]; len(root.neighbors) < c.degree; {
+		root.neighbors = append(root.neighbors, c.random(r))
+	}
+}
+
+func (c *compactChurn) bfs() int { return c.g.bfs(0) }
+
+func (c *compactChurn) size() (nodes, edges int) {
+	for i := range c.g.nodes {
+		edges += len(c.g.nodes[i].neighbors)
+	}
+	return c.g.size, edges
+}
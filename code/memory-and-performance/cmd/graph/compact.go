@@ -1,8 +1,9 @@
 package main
 
 import (
-	"math/rand"
 	"sync"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
 )
 
 type compactGraph struct {
@@ -70,26 +71,18 @@ func (g *compactGraph) bfs(startID int) int {
 	return count
 }
 
-func createCompactGraph(size int) *compactGraph {
-	nodes := make([]compactNode, size)
-
-	for i := 0; i < size; i++ {
+func createCompactGraph(e *graphdata.Edges) *compactGraph {
+	nodes := make([]compactNode, e.Nodes)
+	for i, d := range e.Degrees() {
 		nodes[i].id = i
-		expectedConnections := 5 // Average connections
-		nodes[i].neighbors = make([]int, 0, expectedConnections)
+		nodes[i].neighbors = make([]int, 0, d)
 	}
-
-	// Build connections
-	for i := 0; i < size; i++ {
-		connections := rand.Intn(10) + 1
-		for j := 0; j < connections; j++ {
-			target := rand.Intn(size)
-			nodes[i].neighbors = append(nodes[i].neighbors, target)
-		}
+	for i := range e.Src {
+		from := &nodes[e.Src[i]]
+		from.neighbors = append(from.neighbors, int(e.Dst[i]))
 	}
-
 	return &compactGraph{
 		nodes: nodes,
-		size:  size,
+		size:  e.Nodes,
 	}
 }
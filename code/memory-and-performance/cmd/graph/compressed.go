@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"unsafe"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+)
+
+// compressedGraph stores every node's sorted neighbour list in one byte
+// slice, decoded on the fly during traversal. It trades CPU for memory
+// traffic: compactNode.neighbors spends 8 bytes per edge, int32 spends 4 and
+// varint usually 1-3, since sorted neighbours are close to each other.
+type compressedGraph struct {
+	encoding string
+	offsets  []uint32 // node i's list is data[offsets[i]:offsets[i+1]]
+	data     []byte
+	edges    int
+
+	// The input's weights in the order of the sorted lists: the j-th
+	// neighbour of node i weighs weights[edgeOffsets[i]+j]. Both are nil for
+	// hashed weights.
+	weights     []float64
+	edgeOffsets []int32
+}
+
+const (
+	// varint stores the first neighbour and then the gaps between sorted
+	// neighbours as unsigned varints.
+	varintEncoding = "varint"
+	// int32 stores sorted neighbours as fixed 4-byte little endian values.
+	int32Encoding = "int32"
+)
+
+// createCompressedGraph fails when e has more edges than CSR takes or the
+// encoded lists outgrow the uint32 offsets, 4GiB.
+func createCompressedGraph(e *graphdata.Edges, encoding string) (*compressedGraph, error) {
+	offsets, edges, err := e.CSR()
+	if err != nil {
+		return nil, err
+	}
+	g := &compressedGraph{
+		encoding: encoding,
+		offsets:  make([]uint32, e.Nodes+1),
+		edges:    len(edges),
+	}
+	if encoding == int32Encoding {
+		g.data = make([]byte, 0, 4*len(edges))
+	} else {
+		g.data = make([]byte, 0, 2*len(edges))
+	}
+	if g.weights = e.CSRWeights(offsets); g.weights != nil {
+		g.edgeOffsets = offsets
+	}
+
+	for i := 0; i < e.Nodes; i++ {
+		list := edges[offsets[i]:offsets[i+1]]
+		if g.weights != nil {
+			sort.Sort(byNeighbor{list, g.weights[offsets[i]:offsets[i+1]]})
+		} else {
+			slices.Sort(list)
+		}
+		prev := int32(0)
+		for _, n := range list {
+			if encoding == int32Encoding {
+				g.data = binary.LittleEndian.AppendUint32(g.data, uint32(n))
+			} else {
+				g.data = binary.AppendUvarint(g.data, uint64(n-prev))
+				prev = n
+			}
+		}
+		if uint64(len(g.data)) > math.MaxUint32 {
+			return nil, fmt.Errorf("%s adjacency over %d bytes", encoding, uint64(math.MaxUint32))
+		}
+		g.offsets[i+1] = uint32(len(g.data))
+	}
+	g.data = slices.Clip(g.data)
+	return g, nil
+}
+
+// byNeighbor sorts a neighbour list together with its weights.
+type byNeighbor struct {
+	list    []int32
+	weights []float64
+}
+
+func (s byNeighbor) Len() int           { return len(s.list) }
+func (s byNeighbor) Less(i, j int) bool { return s.list[i] < s.list[j] }
+func (s byNeighbor) Swap(i, j int) {
+	s.list[i], s.list[j] = s.list[j], s.list[i]
+	s.weights[i], s.weights[j] = s.weights[j], s.weights[i]
+}
+
+func (g *compressedGraph) size() int {
+	return len(g.offsets) - 1
+}
+
+// decode calls fn with the index and id of every neighbour of id.
+func (g *compressedGraph) decode(id int32, fn func(j int, n int32)) {
+	list := g.data[g.offsets[id]:g.offsets[id+1]]
+	if g.encoding == int32Encoding {
+		for j := 0; len(list) > 0; j, list = j+1, list[4:] {
+			fn(j, int32(binary.LittleEndian.Uint32(list)))
+		}
+		return
+	}
+	n := int32(0)
+	for j := 0; len(list) > 0; j++ {
+		gap, k := binary.Uvarint(list)
+		list = list[k:]
+		n += int32(gap)
+		fn(j, n)
+	}
+}
+
+// shortestPaths runs Dijkstra from startID like csrGraph.shortestPaths.
+func (g *compressedGraph) shortestPaths(startID int32) (reached int, total float64) {
+	return flatShortestPaths(g.size(), startID, func(id int32, relax func(int32, float64)) {
+		g.decode(id, func(j int, n int32) {
+			if g.weights != nil {
+				relax(n, g.weights[int(g.edgeOffsets[id])+j])
+			} else {
+				relax(n, edgeWeight(int(id), int(n)))
+			}
+		})
+	})
+}
+
+// bfs counts the nodes reachable from startID like csrGraph.bfs, decoding
+// each neighbour list as it is expanded.
+func (g *compressedGraph) bfs(startID int32) int {
+	visited := newBitset(g.size())
+	queue := make([]int32, 0, g.size())
+
+	visited.set(startID)
+	queue = append(queue, startID)
+
+	visit := func(n int32) {
+		if !visited.has(n) {
+			visited.set(n)
+			queue = append(queue, n)
+		}
+	}
+
+	for head := 0; head < len(queue); head++ {
+		id := queue[head]
+		list := g.data[g.offsets[id]:g.offsets[id+1]]
+		if g.encoding == int32Encoding {
+			for ; len(list) > 0; list = list[4:] {
+				visit(int32(binary.LittleEndian.Uint32(list)))
+			}
+			continue
+		}
+		n := int32(0)
+		for len(list) > 0 {
+			gap, k := binary.Uvarint(list)
+			list = list[k:]
+			n += int32(gap)
+			visit(n)
+		}
+	}
+
+	return len(queue)
+}
+
+// bytes returns the memory holding the adjacency: offsets and data, plus
+// the weights when the input has them.
+func (g *compressedGraph) bytes() int {
+	return len(g.offsets)*4 + cap(g.data) + len(g.weights)*8 + len(g.edgeOffsets)*4
+}
+
+// bytes returns the memory holding the adjacency: the node slice and every
+// neighbour slice's backing array.
+func (g *compactGraph) bytes() int {
+	total := len(g.nodes) * int(unsafe.Sizeof(compactNode{}))
+	for i := range g.nodes {
+		total += cap(g.nodes[i].neighbors) * int(unsafe.Sizeof(int(0)))
+	}
+	return total
+}
+
+func (g *csrGraph) bytes() int {
+	return (len(g.offsets)+len(g.edges))*4 + len(g.weights)*8
+}
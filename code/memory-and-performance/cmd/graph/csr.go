@@ -0,0 +1,87 @@
+package main
+
+import "github.com/Elvis339/go_gc_eval/internal/graphdata"
+
+// csrGraph stores the whole adjacency in two flat arrays (compressed sparse
+// row): the neighbors of node i are edges[offsets[i]:offsets[i+1]]. Neither
+// array contains pointers, so the GC never has to scan the graph.
+type csrGraph struct {
+	offsets []int32 // len(nodes)+1
+	edges   []int32
+	weights []float64 // parallel to edges, nil for hashed weights
+}
+
+// bitset is a visited set with one bit per node.
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) has(i int32) bool {
+	return b[i>>6]&(1<<(uint(i)&63)) != 0
+}
+
+func (b bitset) set(i int32) {
+	b[i>>6] |= 1 << (uint(i) & 63)
+}
+
+func (g *csrGraph) size() int {
+	return len(g.offsets) - 1
+}
+
+func (g *csrGraph) neighbors(id int32) []int32 {
+	return g.edges[g.offsets[id]:g.offsets[id+1]]
+}
+
+// bfs counts the nodes reachable from startID. Nodes are marked when they
+// are queued, so the queue never holds more than one entry per node and can
+// be a single preallocated slice read by index.
+func (g *csrGraph) bfs(startID int32) int {
+	visited := newBitset(g.size())
+	queue := make([]int32, 0, g.size())
+
+	visited.set(startID)
+	queue = append(queue, startID)
+
+	for head := 0; head < len(queue); head++ {
+		for _, n := range g.neighbors(queue[head]) {
+			if !visited.has(n) {
+				visited.set(n)
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	return len(queue)
+}
+
+// shortestPaths runs Dijkstra from startID over the input's weights, or
+// edgeWeight ones when it had none.
+func (g *csrGraph) shortestPaths(startID int32) (reached int, total float64) {
+	return flatShortestPaths(g.size(), startID, func(id int32, relax func(int32, float64)) {
+		for i := g.offsets[id]; i < g.offsets[id+1]; i++ {
+			to := g.edges[i]
+			if g.weights != nil {
+				relax(to, g.weights[i])
+			} else {
+				relax(to, edgeWeight(int(id), int(to)))
+			}
+		}
+	})
+}
+
+// createCSRGraph lays e out as offsets and edges, plus weights when e has
+// them, without any per-node allocation. It fails when e has more edges than
+// int32 offsets address.
+func createCSRGraph(e *graphdata.Edges) (*csrGraph, error) {
+	offsets, edges, err := e.CSR()
+	if err != nil {
+		return nil, err
+	}
+	return &csrGraph{
+		offsets: offsets,
+		edges:   edges,
+		weights: e.CSRWeights(offsets),
+	}, nil
+}
@@ -1,6 +1,6 @@
 package main
 
-import "math/rand"
+import "github.com/Elvis339/go_gc_eval/internal/graphdata"
 
 type graphNode struct {
 	id        int
@@ -36,19 +36,25 @@ func bfs(root *graphNode) int {
 	return count
 }
 
-func createGraph(size int) *graphNode {
-	nodes := make([]*graphNode, size)
-	for i := 0; i < size; i++ {
-		nodes[i] = &graphNode{id: i}
+// createGraph builds the pointer graph from e and returns node 0. All
+// layouts are built from the same edge list, see internal/graphgen.
+func createGraph(e *graphdata.Edges) *graphNode {
+	if e.Nodes == 0 {
+		return nil
 	}
+	return createGraphNodes(e)[0]
+}
 
-	for i := 0; i < size; i++ {
-		connections := rand.Intn(10) + 1
-		for j := 0; j < connections; j++ {
-			target := rand.Intn(size)
-			nodes[i].neighbors = append(nodes[i].neighbors, nodes[target])
-		}
+// createGraphNodes builds the pointer graph and returns every node, for the
+// algorithms that have to see nodes not reachable from node 0.
+func createGraphNodes(e *graphdata.Edges) []*graphNode {
+	nodes := make([]*graphNode, e.Nodes)
+	for i := range nodes {
+		nodes[i] = &graphNode{id: i}
 	}
-
-	return nodes[0]
+	for i := range e.Src {
+		from := nodes[e.Src[i]]
+		from.neighbors = append(from.neighbors, nodes[e.Dst[i]])
+	}
+	return nodes
 }
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+	"github.com/Elvis339/go_gc_eval/internal/graphgen"
+)
+
+func TestLayoutsMatchBFS(t *testing.T) {
+	for _, topo := range graphgen.Topologies {
+		for _, size := range []int{1, 2, 10, 1000, 50_000} {
+			e, err := graphgen.Generate(graphgen.Config{Topology: topo, Nodes: size, Seed: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := bfs(createGraph(e))
+			if got := createCompactGraph(e).bfs(0); got != want {
+				t.Errorf("%s size %d: compact visited %d nodes, pointer graph %d", topo, size, got, want)
+			}
+			csr, err := createCSRGraph(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := csr.bfs(0); got != want {
+				t.Errorf("%s size %d: csr visited %d nodes, pointer graph %d", topo, size, got, want)
+			}
+			for _, enc := range []string{varintEncoding, int32Encoding} {
+				g, err := createCompressedGraph(e, enc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := g.bfs(0); got != want {
+					t.Errorf("%s size %d: %s visited %d nodes, pointer graph %d", topo, size, enc, got, want)
+				}
+			}
+			compact := createCompactGraph(e)
+			for _, workers := range []int{1, 3, 8} {
+				if got := compact.parallelBFS(0, workers); got != want {
+					t.Errorf("%s size %d: parallel bfs with %d workers visited %d nodes, bfs %d", topo, size, workers, got, want)
+				}
+				if got := compact.dirOptBFS(0, workers); got != want {
+					t.Errorf("%s size %d: direction-optimizing bfs with %d workers visited %d nodes, bfs %d", topo, size, workers, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAlgorithmsMatchAcrossLayouts(t *testing.T) {
+	for _, topo := range graphgen.Topologies {
+		e, err := graphgen.Generate(graphgen.Config{Topology: topo, Nodes: 5000, Seed: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, compact := createGraphNodes(e), createCompactGraph(e)
+
+		if got, want := compact.dfs(0), dfs(nodes[0]); got != want || want != bfs(nodes[0]) {
+			t.Errorf("%s: dfs visited %d (compact) and %d (pointer) nodes, bfs %d", topo, got, want, bfs(nodes[0]))
+		}
+		if got, want := compact.components(), components(nodes); got != want {
+			t.Errorf("%s: %d components (compact), %d (pointer)", topo, got, want)
+		}
+		if topo == graphgen.Chain || topo == graphgen.Grid {
+			if got := compact.components(); got != 1 {
+				t.Errorf("%s: %d components, want 1", topo, got)
+			}
+		}
+
+		ptrRank, rank := pageRank(nodes, 10), compact.pageRank(10)
+		sum := 0.0
+		for i, r := range rank {
+			sum += r
+			if math.Abs(r-ptrRank[i]) > 1e-12 {
+				t.Fatalf("%s: rank of node %d is %g (compact), %g (pointer)", topo, i, r, ptrRank[i])
+			}
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("%s: ranks sum to %g", topo, sum)
+		}
+
+		r1, d1 := compact.shortestPaths(0, nil)
+		r2, d2 := shortestPaths(nodes[0], nil)
+		if r1 != r2 || d1 != d2 || r1 != bfs(nodes[0]) {
+			t.Errorf("%s: sssp reached %d/%g (compact), %d/%g (pointer)", topo, r1, d1, r2, d2)
+		}
+		for name, sssp := range flatShortestPathsOf(t, e) {
+			if r, d := sssp(0); r != r1 || d != d1 {
+				t.Errorf("%s: sssp reached %d/%g (%s), %d/%g (compact)", topo, r, d, name, r1, d1)
+			}
+		}
+	}
+}
+
+// flatShortestPathsOf builds every flat layout of e and returns their sssp.
+func flatShortestPathsOf(t *testing.T, e *graphdata.Edges) map[string]func(int32) (int, float64) {
+	t.Helper()
+	csr, err := createCSRGraph(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layouts := map[string]func(int32) (int, float64){"csr": csr.shortestPaths}
+	for _, enc := range []string{varintEncoding, int32Encoding} {
+		g, err := createCompressedGraph(e, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		layouts[enc] = g.shortestPaths
+	}
+	return layouts
+}
+
+func TestShortestPathsChain(t *testing.T) {
+	e, _ := graphgen.Generate(graphgen.Config{Topology: graphgen.Chain, Nodes: 4})
+	var want, dist float64
+	for i := 0; i < 3; i++ {
+		dist += edgeWeight(i, i+1)
+		want += dist
+	}
+	if _, got := createCompactGraph(e).shortestPaths(0, nil); got != want {
+		t.Errorf("total distance %g, want %g", got, want)
+	}
+}
+
+// testdata/weighted.gr has shortest distances 0, 3, 1, 8 and 11 from node 1.
+// Its cheapest paths avoid the cheapest first edges, so hashed or unit
+// weights give a different total.
+func TestShortestPathsWeighted(t *testing.T) {
+	e, err := graphdata.Load(filepath.Join("testdata", "weighted.gr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWeights(e.Weights); err != nil {
+		t.Fatal(err)
+	}
+	const reached, total = 5, 23
+	check := func(layout string, r int, d float64) {
+		if r != reached || d != total {
+			t.Errorf("%s: reached %d nodes at total distance %g, want %d and %d", layout, r, d, reached, total)
+		}
+	}
+
+	w, err := newWeightTable(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, d := shortestPaths(createGraphNodes(e)[0], w)
+	check("ptr-chasing", r, d)
+	g := createCompactGraph(e)
+	r, d = g.shortestPaths(0, w)
+	check("compact", r, d)
+	perm := rcmOrder(g)
+	r, d = g.reorder(perm).shortestPaths(perm[0], w)
+	check("compact rcm", r, d)
+	for name, sssp := range flatShortestPathsOf(t, e) {
+		r, d := sssp(0)
+		check(name, r, d)
+	}
+}
+
+func TestCheckWeights(t *testing.T) {
+	for _, w := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if checkWeights([]float64{1, w}) == nil {
+			t.Errorf("weight %g accepted", w)
+		}
+	}
+	if err := checkWeights([]float64{0, 2.5}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReorderPreservesGraph(t *testing.T) {
+	for _, topo := range graphgen.Topologies {
+		e, err := graphgen.Generate(graphgen.Config{Topology: topo, Nodes: 5000, Seed: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := createCompactGraph(e)
+		want, wantCC := g.bfs(0), g.components()
+		wantRank := g.pageRank(5)
+		wantReached, wantDist := g.shortestPaths(0, nil)
+
+		for name, order := range reorderings {
+			perm := order(g)
+			seen := make([]bool, len(perm))
+			for _, p := range perm {
+				if p < 0 || p >= len(perm) || seen[p] {
+					t.Fatalf("%s %s: not a permutation", topo, name)
+				}
+				seen[p] = true
+			}
+
+			r := g.reorder(perm)
+			if got := r.bfs(perm[0]); got != want {
+				t.Errorf("%s %s: visited %d nodes, %d before reordering", topo, name, got, want)
+			}
+			if got := r.components(); got != wantCC {
+				t.Errorf("%s %s: %d components, %d before reordering", topo, name, got, wantCC)
+			}
+			if reached, dist := r.shortestPaths(perm[0], nil); reached != wantReached || dist != wantDist {
+				t.Errorf("%s %s: sssp reached %d nodes at total distance %g, %d and %g before reordering", topo, name, reached, dist, wantReached, wantDist)
+			}
+			rank := r.pageRank(5)
+			for old, p := range perm {
+				if r.nodes[p].id != old || math.Abs(rank[p]-wantRank[old]) > 1e-12 {
+					t.Fatalf("%s %s: node %d moved to %d with id %d and rank %g, want rank %g", topo, name, old, p, r.nodes[p].id, rank[p], wantRank[old])
+				}
+			}
+		}
+	}
+}
+
+func TestReorderReducesDistance(t *testing.T) {
+	e, _ := graphgen.Generate(graphgen.Config{Topology: graphgen.Grid, Nodes: 10_000, Seed: 1})
+	g := createCompactGraph(e)
+	if got, want := g.neighborDistance(), edgeDistance(e); got != want {
+		t.Fatalf("neighborDistance %g, edgeDistance %g", got, want)
+	}
+	// Shuffle the grid, then RCM should get neighbours close again.
+	perm := make([]int, len(g.nodes))
+	for i := range perm {
+		perm[i] = (i * 7919) % len(perm)
+	}
+	shuffled := g.reorder(perm)
+	after := shuffled.reorder(rcmOrder(shuffled))
+	if before, got := shuffled.neighborDistance(), after.neighborDistance(); got > before/10 {
+		t.Errorf("rcm distance %g, shuffled %g", got, before)
+	}
+}
+
+func TestStrategiesMatchBFS(t *testing.T) {
+	for _, topo := range graphgen.Topologies {
+		e, err := graphgen.Generate(graphgen.Config{Topology: topo, Nodes: 3000, Seed: 9})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, compact := createGraphNodes(e), createCompactGraph(e)
+		want := bfs(nodes[0])
+
+		for _, vs := range visitedSets {
+			for _, qs := range queues {
+				set, err := newNodeVisitedSet(vs, nodes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				q, _ := newQueue[*graphNode](qs)
+				// Twice, the second traversal checks Reset.
+				for run := 0; run < 2; run++ {
+					if got := bfsWith(nodes[0], set, q); got != want {
+						t.Errorf("%s ptr-chasing %s/%s run %d: visited %d nodes, bfs %d", topo, vs, qs, run, got, want)
+					}
+				}
+
+				ids, err := newVisitedSet(vs, len(compact.nodes))
+				if vs == "node" {
+					if err == nil {
+						t.Errorf("compact accepted visited set %q", vs)
+					}
+					continue
+				}
+				qi, _ := newQueue[int](qs)
+				for run := 0; run < 2; run++ {
+					if got := compact.bfsWith(0, ids, qi); got != want {
+						t.Errorf("%s compact %s/%s run %d: visited %d nodes, bfs %d", topo, vs, qs, run, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestQueuesAreFIFO(t *testing.T) {
+	for _, qs := range queues {
+		q, err := newQueue[int](qs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := 0
+		for i := 0; i < 100; i++ {
+			q.Push(i)
+			if i%3 == 0 {
+				n, _ := q.Pop()
+				if n != next {
+					t.Fatalf("%s: popped %d, want %d", qs, n, next)
+				}
+				next++
+			}
+		}
+		for ; next < 100; next++ {
+			if n, ok := q.Pop(); !ok || n != next {
+				t.Fatalf("%s: popped %d %t, want %d", qs, n, ok, next)
+			}
+		}
+		if _, ok := q.Pop(); ok {
+			t.Errorf("%s: pop from an empty queue", qs)
+		}
+	}
+}
+
+func TestEpochSetWraps(t *testing.T) {
+	s := &epochSet{stamps: make([]uint32, 4), epoch: math.MaxUint32 - 1}
+	s.Reset()
+	s.Visit(2)
+	s.Reset() // wraps
+	if !s.Visit(2) || s.Visit(2) {
+		t.Error("node 2 not fresh after the epoch wrapped")
+	}
+}
+
+func TestChurnKeepsGraphConsistent(t *testing.T) {
+	e, err := graphgen.Generate(graphgen.Config{Topology: graphgen.Uniform, Nodes: 5000, Seed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"ptr-chasing", "compact"} {
+		c := newChurner(v, e)
+		r := rand.New(rand.NewPCG(1, 2))
+		for i := 0; i < 50; i++ {
+			c.step(r, 50)
+		}
+		if nodes, _ := c.size(); nodes != e.Nodes {
+			t.Errorf("%s: %d nodes after churn, want %d", v, nodes, e.Nodes)
+		}
+		if got := c.bfs(); got < 2 || got > e.Nodes {
+			t.Errorf("%s: bfs visited %d nodes", v, got)
+		}
+
+		switch c := c.(type) {
+		case *ptrChurn:
+			for _, n := range c.nodes {
+				for _, nb := range n.neighbors {
+					if nb.id < 0 {
+						t.Fatalf("ptr-chasing: node %d links to a removed node", n.id)
+					}
+				}
+			}
+		case *compactChurn:
+			for i, n := range c.g.nodes {
+				for _, nb := range n.neighbors {
+					if n.id != i || c.g.nodes[nb].id < 0 {
+						t.Fatalf("compact: node %d (id %d) links to removed node %d", i, n.id, nb)
+					}
+				}
+			}
+		}
+	}
+}
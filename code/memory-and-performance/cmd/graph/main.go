@@ -4,89 +4,388 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"runtime"
-	"runtime/pprof"
+	"slices"
+	"strconv"
 	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+	"github.com/Elvis339/go_gc_eval/internal/graphgen"
+	"github.com/Elvis339/go_gc_eval/internal/harness"
 )
 
-func getExecutableName() string {
-	executable, err := os.Executable()
+// make run EXEC=graph ARGS="-s 100000 -p"
+// make run EXEC=graph ARGS="-v compact -s 100000 -p"
+// make run EXEC=graph ARGS="-v csr -s 100000 -p"
+// make run EXEC=graph ARGS="-v varint -s 2000000"
+// make run EXEC=graph ARGS="-v compact -g ba -s 100000 -seed 42"
+// make run EXEC=graph ARGS="-v compact -w 8 -diropt"
+// make run EXEC=graph ARGS="-v compact -g rmat -reorder rcm"
+// make run EXEC=graph ARGS="-v ptr-chasing -visited node -queue ring"
+// make run EXEC=graph ARGS="-v compact -churn 1m -metrics 250ms"
+// make run EXEC=graph ARGS="-s 2000000 -save traces/uniform-2m.snap"
+// make run EXEC=graph ARGS="-v csr -load traces/uniform-2m.snap -mmap"
+// make run EXEC=graph ARGS="-v compact -in data/web-Google.txt.gz"
+// make run EXEC=graph ARGS="-v ptr-chasing -algo pagerank -iters 10"
+func main() {
+	opts := harness.RegisterFlags(flag.CommandLine)
+	version := flag.String("v", "", "Implementation: ptr-chasing (default), compact, csr, varint or int32")
+	size := flag.Int("s", 1_000_000, "Number of nodes in the graph")
+	topology := flag.String("g", string(graphgen.Uniform), "Generated topology: uniform, er, ba, rmat, grid or chain")
+	degree := flag.Int("degree", 0, "Degree parameter of the topology, 0 uses its default (see README)")
+	seed := flag.Uint64("seed", 1, "Seed of the graph generator, the same seed gives the same graph in every variant and build")
+	workers := flag.Int("w", 0, "BFS workers for -v compact, 0 runs the serial bfs")
+	diropt := flag.Bool("diropt", false, "Use the direction-optimizing BFS with -w")
+	algo := flag.String("algo", "bfs", "Algorithm: bfs, dfs, cc (connected components), pagerank or sssp (Dijkstra over the -in file's weights, hashed ones when it has none)")
+	iters := flag.Int("iters", 20, "PageRank iterations")
+	reorder := flag.String("reorder", "", "Relabel -v compact nodes before the traversal: bfs, degree or rcm")
+	visitedSet := flag.String("visited", "", "BFS visited set: map, bitset, epoch or node (ptr-chasing only); empty runs the built-in bfs")
+	queue := flag.String("queue", "", "BFS queue: slice, ring or frontier; empty runs the built-in bfs")
+	churnFor := flag.Duration("churn", 0, "Keep adding and removing nodes and edges for this long, with a BFS every few steps (ptr-chasing and compact)")
+	save := flag.String("save", "", "Write the graph to this snapshot file before running")
+	load := flag.String("load", "", "Load the graph from a snapshot written by -save instead of generating it")
+	mmap := flag.Bool("mmap", false, "Map the -load snapshot instead of reading it, -v csr then traverses it in place outside the Go heap")
+	in := flag.String("in", "", "Load the graph from a SNAP edge list, Matrix Market (.mtx) or DIMACS (.gr) file instead of generating it; .gz is decompressed")
+	flag.Parse()
+
+	v := *version
+	if len(v) == 0 {
+		v = "ptr-chasing"
+	}
+	// The flat layouts only implement the serial bfs and sssp.
+	flat := v == "csr" || v == varintEncoding || v == int32Encoding
+	if *workers < 0 || (*workers > 0 || *diropt) && (v != "compact" || *algo != "bfs") {
+		log.Fatal("-w and -diropt need -v compact, -algo bfs and a positive worker count")
+	}
+	variant := v
+	switch *algo {
+	case "bfs":
+	case "dfs", "cc", "pagerank", "sssp":
+		if flat && *algo != "sssp" {
+			log.Fatalf("-algo %s is implemented for ptr-chasing and compact", *algo)
+		}
+		// Artifacts of different algorithms must not overwrite each other.
+		variant += "-" + *algo
+	default:
+		log.Fatalf("unknown algorithm %q", *algo)
+	}
+	order, err := parseReorder(*reorder)
 	if err != nil {
-		return "unknown"
+		log.Fatal(err)
+	}
+	if order != nil {
+		if v != "compact" {
+			log.Fatal("-reorder needs -v compact")
+		}
+		variant += "-" + *reorder
+	}
+	strategies := *visitedSet != "" || *queue != ""
+	if strategies {
+		if flat || *algo != "bfs" || *workers > 0 {
+			log.Fatal("-visited and -queue need -v ptr-chasing or compact, -algo bfs and no -w")
+		}
+		if *visitedSet == "" {
+			*visitedSet = "map"
+		}
+		if *queue == "" {
+			*queue = "slice"
+		}
+		if !slices.Contains(visitedSets, *visitedSet) || !slices.Contains(queues, *queue) {
+			log.Fatalf("unknown -visited %q or -queue %q, want one of %v and %v", *visitedSet, *queue, visitedSets, queues)
+		}
+		if *visitedSet == "node" && v != "ptr-chasing" {
+			log.Fatal("-visited node needs -v ptr-chasing")
+		}
+		variant += "-" + *visitedSet + "-" + *queue
+	}
+	if *churnFor > 0 {
+		if flat || *algo != "bfs" || *workers > 0 || order != nil || strategies {
+			log.Fatal("-churn needs -v ptr-chasing or compact with the plain bfs")
+		}
+		variant += "-churn"
+	}
+	if *load != "" && (*in != "" || *save != "") {
+		log.Fatal("-load cannot be combined with -in or -save")
+	}
+	if *mmap {
+		if *load == "" || v != "csr" {
+			log.Fatal("-mmap needs -load and -v csr")
+		}
+		variant += "-mmap"
+	}
+	if *diropt && *workers == 0 {
+		*workers = runtime.GOMAXPROCS(0)
 	}
-	return filepath.Base(executable)
-}
 
-func startProfiling(enable bool, execName string) func() {
-	if !enable {
-		return func() {}
+	// Generating or loading the edge list is setup, building the layout from
+	// it is measured. A snapshot already is the csr layout.
+	var (
+		edges  *graphdata.Edges
+		snap   *graphdata.Snapshot
+		source string
+	)
+	if *load != "" {
+		if *mmap {
+			snap, err = graphdata.MapSnapshot(*load)
+			source = fmt.Sprintf("%s (snapshot, mapped)", *load)
+		} else {
+			snap, err = graphdata.ReadSnapshot(*load)
+			source = fmt.Sprintf("%s (snapshot)", *load)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if snap.Nodes() == 0 {
+			log.Fatalf("%s: empty graph", *load)
+		}
+		if v != "csr" {
+			edges = snap.EdgeList()
+			snap = nil
+		}
+	} else if *in != "" {
+		edges, err = graphdata.Load(*in)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if edges.Nodes == 0 {
+			log.Fatalf("%s: empty graph", *in)
+		}
+		source = fmt.Sprintf("%s (%s)", *in, graphdata.FormatOf(*in))
+		if *algo != "sssp" {
+			// Only sssp reads weights. Dropping them keeps every other
+			// layout the same size for weighted and unweighted files.
+			edges.Weights = nil
+		} else if err := checkWeights(edges.Weights); err != nil {
+			log.Fatalf("%s: %v", *in, err)
+		}
+	} else {
+		topo, err := graphgen.ParseTopology(*topology)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg := graphgen.Config{Topology: topo, Nodes: *size, Degree: *degree, Seed: *seed}
+		edges, err = graphgen.Generate(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		source = cfg.String()
+	}
+	if *save != "" {
+		if err := graphdata.WriteSnapshot(*save, edges); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Saved snapshot %s\n", *save)
+	}
+	nodes, edgeCount := 0, 0
+	if snap != nil {
+		nodes, edgeCount = snap.Nodes(), len(snap.Edges)
+	} else {
+		nodes, edgeCount = edges.Nodes, edges.Len()
 	}
 
-	cpuFile, err := os.Create(filepath.Join("traces", fmt.Sprintf("%s_cpu.pprof", execName)))
-	if err != nil {
-		log.Fatal("Failed to create CPU profile file:", err)
+	fmt.Printf("Configuration:\n")
+	fmt.Printf("  Implementation: %s\n", v)
+	fmt.Printf("  Algorithm: %s\n", *algo)
+	fmt.Printf("  Graph: %s\n", source)
+	fmt.Printf("  Graph size: %d nodes, %d edges\n", nodes, edgeCount)
+	if *workers > 0 {
+		fmt.Printf("  BFS: %d workers, direction-optimizing %t\n", *workers, *diropt)
 	}
+	var distance float64
+	if order != nil {
+		distance = edgeDistance(edges)
+		fmt.Printf("  Reorder: %s\n", *reorder)
+	}
+	if strategies {
+		fmt.Printf("  BFS: %s visited set, %s queue\n", *visitedSet, *queue)
+	}
+	if *churnFor > 0 {
+		fmt.Printf("  Churn: %s\n", *churnFor)
+	}
+	fmt.Printf("  Profiling: %t\n", opts.Profile)
+	fmt.Printf("\n")
 
-	if err := pprof.StartCPUProfile(cpuFile); err != nil {
-		cpuFile.Close()
-		log.Fatal("Failed to start CPU profiling:", err)
+	h, err := harness.New(opts, variant)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	return func() {
-		pprof.StopCPUProfile()
-		cpuFile.Close()
+	var (
+		result    string
+		reordered *compactGraph
+		reorderIn time.Duration
 
-		runtime.GC()
-		memFile, err := os.Create(filepath.Join("traces", fmt.Sprintf("%s_mem.pprof", execName)))
-		if err != nil {
-			log.Printf("Failed to create memory profile file: %v", err)
+		// Size of the adjacency and time of the traversal alone, for the
+		// index based layouts.
+		layoutBytes int
+		traversal   time.Duration
+	)
+	duration, err := h.Measure(func() {
+		if *churnFor > 0 {
+			c := newChurner(v, edges)
+			edges = nil
+			result = churn(c, *churnFor, *seed).String()
 			return
 		}
-		defer memFile.Close()
+		switch *version {
+		case "compact":
+			graph := createCompactGraph(edges)
+			weights, err := newWeightTable(edges)
+			if err != nil {
+				log.Fatal(err)
+			}
+			edges = nil // only the layout stays live during the traversal
+			start := 0
+			if order != nil {
+				t := time.Now()
+				perm := order(graph)
+				graph = graph.reorder(perm)
+				start = perm[0]
+				reorderIn = time.Since(t)
+				reordered = graph
+			}
+			layoutBytes = graph.bytes() + weights.bytes()
+			t := time.Now()
+			switch {
+			case *diropt:
+				result = visited(graph.dirOptBFS(start, *workers))
+			case *workers > 0:
+				result = visited(graph.parallelBFS(start, *workers))
+			case strategies:
+				set, _ := newVisitedSet(*visitedSet, len(graph.nodes))
+				q, _ := newQueue[int](*queue)
+				result = visited(graph.bfsWith(start, set, q))
+			default:
+				result = runCompact(graph, start, *algo, *iters, weights)
+			}
+			traversal = time.Since(t)
+		case "csr":
+			var graph *csrGraph
+			if snap != nil {
+				graph = &csrGraph{offsets: snap.Offsets, edges: snap.Edges}
+			} else {
+				g, err := createCSRGraph(edges)
+				if err != nil {
+					log.Fatal(err)
+				}
+				graph = g
+			}
+			edges = nil
+			layoutBytes = graph.bytes()
+			t := time.Now()
+			if *algo == "sssp" {
+				result = distances(graph.shortestPaths(0))
+			} else {
+				result = visited(graph.bfs(0))
+			}
+			traversal = time.Since(t)
+		case varintEncoding, int32Encoding:
+			graph, err := createCompressedGraph(edges, *version)
+			if err != nil {
+				log.Fatal(err)
+			}
+			edges = nil
+			layoutBytes = graph.bytes()
+			t := time.Now()
+			if *algo == "sssp" {
+				result = distances(graph.shortestPaths(0))
+			} else {
+				result = visited(graph.bfs(0))
+			}
+			traversal = time.Since(t)
+		default:
+			nodes := createGraphNodes(edges)
+			weights, err := newWeightTable(edges)
+			if err != nil {
+				log.Fatal(err)
+			}
+			edges = nil
+			if strategies {
+				set, _ := newNodeVisitedSet(*visitedSet, nodes)
+				q, _ := newQueue[*graphNode](*queue)
+				result = visited(bfsWith(nodes[0], set, q))
+				break
+			}
+			result = runPointer(nodes, *algo, *iters, weights)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result)
+	fmt.Println("Execution time", duration)
+	if layoutBytes > 0 {
+		fmt.Println("Traversal time", traversal)
+		fmt.Printf("Adjacency: %.1fMB, %.2f bytes/edge\n", float64(layoutBytes)/(1<<20), float64(layoutBytes)/float64(max(1, edgeCount)))
+	}
+	if reordered != nil {
+		fmt.Println("Reorder time", reorderIn)
+		fmt.Printf("Average neighbour distance: %.1f before, %.1f after\n", distance, reordered.neighborDistance())
+		reordered = nil
+	}
 
-		if err := pprof.WriteHeapProfile(memFile); err != nil {
-			log.Printf("Failed to write heap profile: %v", err)
-			return
+	if err := h.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if snap != nil {
+		if err := snap.Close(); err != nil {
+			log.Fatal(err)
 		}
 	}
 }
 
-// make run EXEC=graph ARGS="-s 100000 -p"
-// make run EXEC=graph ARGS="-v compact -s 100000 -p"
-func main() {
-	version := flag.String("v", "", "Add compact flag if you want to run optimized version")
-	size := flag.Int("s", 1_000_000, "Number of nodes in the graph")
-	enableProfiling := flag.Bool("p", false, "Enable CPU and memory profiling")
-	flag.Parse()
+func visited(n int) string {
+	return fmt.Sprintf("Visited %d nodes", n)
+}
 
-	execName := getExecutableName()
+func distances(reached int, total float64) string {
+	return fmt.Sprintf("Reached %d nodes, total distance %s", reached, strconv.FormatFloat(total, 'f', -1, 64))
+}
 
-	v := *version
-	if len(v) == 0 {
-		v = "ptr-chasing"
+// runPointer runs algo from node 0, sssp over weights.
+func runPointer(nodes []*graphNode, algo string, iters int, weights *weightTable) string {
+	switch algo {
+	case "dfs":
+		return visited(dfs(nodes[0]))
+	case "cc":
+		return fmt.Sprintf("Components: %d", components(nodes))
+	case "pagerank":
+		rank := pageRank(nodes, iters)
+		return topRank(len(nodes), func(id int) float64 { return rank[id] }, nil)
+	case "sssp":
+		return distances(shortestPaths(nodes[0], weights))
 	}
+	return visited(bfs(nodes[0]))
+}
 
-	fmt.Printf("Configuration:\n")
-	fmt.Printf("  Implementation: %s\n", v)
-	fmt.Printf("  Graph size: %d nodes\n", *size)
-	fmt.Printf("  Profiling: %t\n", *enableProfiling)
-	fmt.Printf("\n")
-
-	stopProfiling := startProfiling(*enableProfiling, fmt.Sprintf("%s_%s", execName, v))
-	defer stopProfiling()
-
-	start := time.Now()
-	switch *version {
-	case "compact":
-		graph := createCompactGraph(*size)
-		runtime.KeepAlive(graph.bfs(0))
-	default:
-		graph := createGraph(*size)
-		runtime.KeepAlive(bfs(graph))
+// runCompact runs algo from start, sssp over weights. Node ids, not indices,
+// are reported so a reordered graph prints the same node as the original.
+func runCompact(g *compactGraph, start int, algo string, iters int, weights *weightTable) string {
+	switch algo {
+	case "dfs":
+		return visited(g.dfs(start))
+	case "cc":
+		return fmt.Sprintf("Components: %d", g.components())
+	case "pagerank":
+		rank := g.pageRank(iters)
+		return topRank(len(rank), func(id int) float64 { return rank[id] }, func(i int) int { return g.nodes[i].id })
+	case "sssp":
+		return distances(g.shortestPaths(start, weights))
 	}
+	return visited(g.bfs(start))
+}
 
-	duration := time.Since(start)
-	fmt.Println("Execution time", duration)
+// topRank returns the node with the highest rank. label maps an index to the
+// reported id, nil reports the index.
+func topRank(n int, rank func(id int) float64, label func(i int) int) string {
+	best, id := -1.0, 0
+	for i := 0; i < n; i++ {
+		if r := rank(i); r > best {
+			best, id = r, i
+		}
+	}
+	if label != nil {
+		id = label(id)
+	}
+	return fmt.Sprintf("Highest rank: node %d (%.6g)", id, best)
 }
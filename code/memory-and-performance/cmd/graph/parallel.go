@@ -0,0 +1,184 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// atomicBitset is a visited set that many workers mark concurrently.
+type atomicBitset []uint64
+
+func newAtomicBitset(n int) atomicBitset {
+	return make(atomicBitset, (n+63)/64)
+}
+
+func (b atomicBitset) has(i int) bool {
+	return atomic.LoadUint64(&b[i>>6])&(1<<(uint(i)&63)) != 0
+}
+
+// trySet marks i and reports whether this call was the one that marked it.
+func (b atomicBitset) trySet(i int) bool {
+	mask := uint64(1) << (uint(i) & 63)
+	return atomic.OrUint64(&b[i>>6], mask)&mask == 0
+}
+
+// parallelBFS is a level-synchronous BFS: the frontier is split between
+// workers, each collecting the nodes it discovers in its own next frontier,
+// and the levels are joined between iterations. It visits the same nodes as
+// bfs.
+func (g *compactGraph) parallelBFS(startID, workers int) int {
+	visited := newAtomicBitset(len(g.nodes))
+	visited.trySet(startID)
+	frontier := []int{startID}
+	count := 1
+
+	next := make([][]int, workers)
+	for len(frontier) > 0 {
+		parallelChunks(len(frontier), workers, func(w, lo, hi int) {
+			local := next[w][:0]
+			for _, id := range frontier[lo:hi] {
+				for _, n := range g.nodes[id].neighbors {
+					if visited.trySet(n) {
+						local = append(local, n)
+					}
+				}
+			}
+			next[w] = local
+		})
+		frontier = frontier[:0]
+		for _, local := range next {
+			frontier = append(frontier, local...)
+		}
+		count += len(frontier)
+	}
+	return count
+}
+
+// Thresholds of the direction-optimizing BFS from Beamer et al., "Direction-
+// Optimizing Breadth-First Search" (SC'12).
+const (
+	dirOptAlpha = 14 // go bottom-up when frontier edges > unexplored edges / alpha
+	dirOptBeta  = 24 // go back top-down when the frontier < nodes / beta
+)
+
+// dirOptBFS switches between top-down steps, which expand the frontier, and
+// bottom-up steps, where every unvisited node looks for a parent in the
+// frontier and stops at the first one. Bottom-up wins when the frontier
+// holds a large part of the graph. It needs incoming edges, so the reverse
+// graph is built first. It visits the same nodes as bfs.
+func (g *compactGraph) dirOptBFS(startID, workers int) int {
+	n := len(g.nodes)
+	in := g.reverse()
+
+	visited := newAtomicBitset(n)
+	visited.trySet(startID)
+	frontier := []int{startID}
+	count := 1
+
+	var unexplored int64 // out-edges of unvisited nodes
+	for i := range g.nodes {
+		unexplored += int64(len(g.nodes[i].neighbors))
+	}
+	unexplored -= int64(len(g.nodes[startID].neighbors))
+
+	next := make([][]int, workers)
+	edges := make([]int64, workers)
+	for len(frontier) > 0 {
+		var frontierEdges int64
+		for _, id := range frontier {
+			frontierEdges += int64(len(g.nodes[id].neighbors))
+		}
+
+		if frontierEdges > unexplored/dirOptAlpha && len(frontier) >= n/dirOptBeta {
+			inFrontier := newAtomicBitset(n)
+			for _, id := range frontier {
+				inFrontier.trySet(id)
+			}
+			// Workers own ranges of 64 node words, so every unvisited node
+			// is claimed by exactly one of them.
+			parallelChunks((n+63)/64, workers, func(w, lo, hi int) {
+				local := next[w][:0]
+				var found int64
+				for v := lo * 64; v < min(hi*64, n); v++ {
+					if visited.has(v) {
+						continue
+					}
+					for _, u := range in[v] {
+						if inFrontier.has(u) {
+							visited.trySet(v)
+							local = append(local, v)
+							found += int64(len(g.nodes[v].neighbors))
+							break
+						}
+					}
+				}
+				next[w], edges[w] = local, found
+			})
+		} else {
+			parallelChunks(len(frontier), workers, func(w, lo, hi int) {
+				local := next[w][:0]
+				var found int64
+				for _, id := range frontier[lo:hi] {
+					for _, v := range g.nodes[id].neighbors {
+						if visited.trySet(v) {
+							local = append(local, v)
+							found += int64(len(g.nodes[v].neighbors))
+						}
+					}
+				}
+				next[w], edges[w] = local, found
+			})
+		}
+
+		frontier = frontier[:0]
+		for w, local := range next {
+			frontier = append(frontier, local...)
+			unexplored -= edges[w]
+		}
+		count += len(frontier)
+	}
+	return count
+}
+
+// reverse returns the incoming neighbors of every node.
+func (g *compactGraph) reverse() [][]int {
+	indegree := make([]int, len(g.nodes))
+	for i := range g.nodes {
+		for _, n := range g.nodes[i].neighbors {
+			indegree[n]++
+		}
+	}
+	in := make([][]int, len(g.nodes))
+	for i := range in {
+		in[i] = make([]int, 0, indegree[i])
+	}
+	for i := range g.nodes {
+		for _, n := range g.nodes[i].neighbors {
+			in[n] = append(in[n], i)
+		}
+	}
+	return in
+}
+
+// parallelChunks splits [0, n) into at most workers contiguous ranges and
+// runs fn on each in its own goroutine. Small inputs run on the caller.
+func parallelChunks(n, workers int, fn func(w, lo, hi int)) {
+	if workers <= 1 || n < 2*workers {
+		for w := 1; w < workers; w++ {
+			fn(w, 0, 0) // reset the other workers' output
+		}
+		fn(0, 0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for w := 0; w < workers; w++ {
+		lo, hi := min(w*chunk, n), min((w+1)*chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(w, lo, hi)
+		}()
+	}
+	wg.Wait()
+}
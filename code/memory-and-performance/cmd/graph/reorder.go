@@ -0,0 +1,189 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+)
+
+// Reordering relabels the nodes of a compactGraph so that neighbours get
+// nearby indices and g.nodes[id] lands on memory that was just touched. The
+// pointers are gone either way; this isolates what the layout alone buys.
+
+// reorderings maps a -reorder value to the function computing the new label
+// of every node: perm[old] = new.
+var reorderings = map[string]func(g *compactGraph) []int{
+	"bfs":    bfsOrder,
+	"degree": degreeOrder,
+	"rcm":    rcmOrder,
+}
+
+// bfsOrder labels nodes in the order a BFS from node 0 reaches them, then
+// continues with BFS from every node it did not reach.
+func bfsOrder(g *compactGraph) []int {
+	return traversalOrder(g, func(int) []int { return nil }, []int{0})
+}
+
+// degreeOrder puts high out-degree nodes first, hubs end up together at the
+// start of the array.
+func degreeOrder(g *compactGraph) []int {
+	order := make([]int, len(g.nodes))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(len(g.nodes[b].neighbors), len(g.nodes[a].neighbors))
+	})
+	return inverse(order)
+}
+
+// rcmOrder is Reverse Cuthill-McKee on the undirected graph: a BFS from a
+// minimum degree node of every component that visits neighbours by
+// increasing degree, reversed. It minimises the bandwidth of the adjacency
+// matrix, keeping edges close to the diagonal.
+func rcmOrder(g *compactGraph) []int {
+	in := g.reverse()
+	degree := make([]int, len(g.nodes))
+	for i := range degree {
+		degree[i] = len(g.nodes[i].neighbors) + len(in[i])
+	}
+	byDegree := func(a, b int) int { return cmp.Compare(degree[a], degree[b]) }
+
+	starts := make([]int, len(g.nodes))
+	for i := range starts {
+		starts[i] = i
+	}
+	slices.SortStableFunc(starts, byDegree)
+
+	var buf []int // traversalOrder is done with the previous slice when it asks for the next
+	perm := traversalOrder(g, func(id int) []int {
+		buf = append(append(buf[:0], g.nodes[id].neighbors...), in[id]...)
+		slices.SortStableFunc(buf, byDegree)
+		return buf
+	}, starts)
+
+	n := len(perm)
+	for i := range perm {
+		perm[i] = n - 1 - perm[i]
+	}
+	return perm
+}
+
+// traversalOrder labels nodes in BFS order. neighbors overrides the
+// neighbours to expand, nil means the out-edges. starts are the BFS roots
+// in order, the remaining unvisited nodes are used in index order after them.
+func traversalOrder(g *compactGraph, neighbors func(id int) []int, starts []int) []int {
+	n := len(g.nodes)
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = -1
+	}
+	queue := make([]int, 0, n)
+	next := 0
+
+	visit := func(root int) {
+		if perm[root] >= 0 {
+			return
+		}
+		perm[root] = next
+		next++
+		queue = append(queue[:0], root)
+		for head := 0; head < len(queue); head++ {
+			id := queue[head]
+			ns := neighbors(id)
+			if ns == nil {
+				ns = g.nodes[id].neighbors
+			}
+			for _, nb := range ns {
+				if perm[nb] < 0 {
+					perm[nb] = next
+					next++
+					queue = append(queue, nb)
+				}
+			}
+		}
+	}
+
+	for _, s := range starts {
+		visit(s)
+	}
+	for i := 0; i < n; i++ {
+		visit(i)
+	}
+	return perm
+}
+
+func inverse(perm []int) []int {
+	inv := make([]int, len(perm))
+	for i, p := range perm {
+		inv[p] = i
+	}
+	return inv
+}
+
+// reorder returns g with node i moved to index perm[i]. Nodes keep their id
+// and their neighbours in the same order, so results can be reported in the
+// original labels.
+func (g *compactGraph) reorder(perm []int) *compactGraph {
+	nodes := make([]compactNode, len(g.nodes))
+	for old := range g.nodes {
+		n := &nodes[perm[old]]
+		n.id = g.nodes[old].id
+		n.neighbors = make([]int, len(g.nodes[old].neighbors))
+		for j, nb := range g.nodes[old].neighbors {
+			n.neighbors[j] = perm[nb]
+		}
+	}
+	return &compactGraph{
+		nodes: nodes,
+		size:  g.size,
+	}
+}
+
+// neighborDistance returns the average |from - to| over all edges, how far
+// in the node array a traversal jumps per edge.
+func (g *compactGraph) neighborDistance() float64 {
+	var total, edges float64
+	for i := range g.nodes {
+		for _, nb := range g.nodes[i].neighbors {
+			total += float64(abs(i - nb))
+			edges++
+		}
+	}
+	if edges == 0 {
+		return 0
+	}
+	return total / edges
+}
+
+// edgeDistance is neighborDistance of the graph e builds before reordering.
+func edgeDistance(e *graphdata.Edges) float64 {
+	var total float64
+	for i := range e.Src {
+		total += float64(abs(int(e.Src[i]) - int(e.Dst[i])))
+	}
+	if e.Len() == 0 {
+		return 0
+	}
+	return total / float64(e.Len())
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func parseReorder(s string) (func(g *compactGraph) []int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fn, ok := reorderings[s]
+	if !ok {
+		return nil, fmt.Errorf("unknown reordering %q, want bfs, degree or rcm", s)
+	}
+	return fn, nil
+}
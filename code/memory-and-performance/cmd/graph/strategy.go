@@ -0,0 +1,261 @@
+package main
+
+import "fmt"
+
+// The serial bfs functions hard-wire their bookkeeping: a fresh map per call
+// for graphNode, a pooled map for compactGraph and queue = queue[1:] queues
+// that keep the consumed prefix alive. traverse takes both as parameters so
+// each choice can be measured on its own.
+
+// VisitedSet records which nodes a traversal has reached.
+type VisitedSet[T any] interface {
+	// Visit marks n and reports whether it was not marked before.
+	Visit(n T) bool
+	// Reset unmarks every node for the next traversal.
+	Reset()
+}
+
+// Queue is the FIFO of nodes waiting to be expanded.
+type Queue[T any] interface {
+	Push(n T)
+	// Pop removes the oldest node, ok is false when the queue is empty.
+	Pop() (n T, ok bool)
+	// Reset empties the queue, keeping its memory when it has any.
+	Reset()
+}
+
+var (
+	visitedSets = []string{"map", "bitset", "epoch", "node"}
+	queues      = []string{"slice", "ring", "frontier"}
+)
+
+// traverse counts the nodes reachable from start. Nodes are marked when they
+// are queued, so every node is queued once.
+func traverse[T any](start T, neighbors func(T) []T, visited VisitedSet[T], queue Queue[T]) int {
+	visited.Reset()
+	queue.Reset()
+	visited.Visit(start)
+	queue.Push(start)
+	count := 0
+
+	for {
+		current, ok := queue.Pop()
+		if !ok {
+			return count
+		}
+		count++
+		for _, neighbor := range neighbors(current) {
+			if visited.Visit(neighbor) {
+				queue.Push(neighbor)
+			}
+		}
+	}
+}
+
+// bfsWith is bfs over the pointer graph with the given strategies.
+func bfsWith(root *graphNode, visited VisitedSet[*graphNode], queue Queue[*graphNode]) int {
+	return traverse(root, func(n *graphNode) []*graphNode { return n.neighbors }, visited, queue)
+}
+
+func (g *compactGraph) bfsWith(startID int, visited VisitedSet[int], queue Queue[int]) int {
+	return traverse(startID, func(id int) []int { return g.nodes[id].neighbors }, visited, queue)
+}
+
+// newVisitedSet returns a visited set over node ids 0..n-1. The in-node flag
+// exists only in graphNode, see newNodeVisitedSet.
+func newVisitedSet(kind string, n int) (VisitedSet[int], error) {
+	switch kind {
+	case "map":
+		return mapSet{}, nil
+	case "bitset":
+		return bitsetSet(newBitset(n)), nil
+	case "epoch":
+		return &epochSet{stamps: make([]uint32, n)}, nil
+	case "node":
+		return nil, fmt.Errorf("visited set %q needs -v ptr-chasing", kind)
+	}
+	return nil, fmt.Errorf("unknown visited set %q, want map, bitset, epoch or node", kind)
+}
+
+// newNodeVisitedSet returns a visited set over the pointer graph nodes.
+func newNodeVisitedSet(kind string, nodes []*graphNode) (VisitedSet[*graphNode], error) {
+	if kind == "node" {
+		return nodeFlags(nodes), nil
+	}
+	ids, err := newVisitedSet(kind, len(nodes))
+	if err != nil {
+		return nil, err
+	}
+	return byID{ids}, nil
+}
+
+func newQueue[T any](kind string) (Queue[T], error) {
+	switch kind {
+	case "slice":
+		return &sliceQueue[T]{}, nil
+	case "ring":
+		return &ringQueue[T]{}, nil
+	case "frontier":
+		return &frontierQueue[T]{}, nil
+	}
+	return nil, fmt.Errorf("unknown queue %q, want slice, ring or frontier", kind)
+}
+
+// mapSet is the map[int]bool both bfs functions use.
+type mapSet map[int]bool
+
+func (s mapSet) Visit(id int) bool {
+	if s[id] {
+		return false
+	}
+	s[id] = true
+	return true
+}
+
+func (s mapSet) Reset() { clear(s) }
+
+// bitsetSet uses one bit per node, 1/64 of a bool slice and no pointers.
+type bitsetSet bitset
+
+func (s bitsetSet) Visit(id int) bool {
+	if bitset(s).has(int32(id)) {
+		return false
+	}
+	bitset(s).set(int32(id))
+	return true
+}
+
+func (s bitsetSet) Reset() { clear(s) }
+
+// epochSet stamps nodes with the number of the traversal that reached them,
+// so Reset is an increment instead of clearing n entries.
+type epochSet struct {
+	stamps []uint32
+	epoch  uint32
+}
+
+func (s *epochSet) Visit(id int) bool {
+	if s.stamps[id] == s.epoch {
+		return false
+	}
+	s.stamps[id] = s.epoch
+	return true
+}
+
+func (s *epochSet) Reset() {
+	s.epoch++
+	if s.epoch == 0 { // wrapped, old stamps could match again
+		clear(s.stamps)
+		s.epoch = 1
+	}
+}
+
+// byID adapts an id set to the pointer graph.
+type byID struct {
+	ids VisitedSet[int]
+}
+
+func (s byID) Visit(n *graphNode) bool { return s.ids.Visit(n.id) }
+func (s byID) Reset()                  { s.ids.Reset() }
+
+// nodeFlags keeps the mark in graphNode.visited: no extra memory, but the
+// flag lives in the node the traversal dereferences anyway. Reset touches
+// every node.
+type nodeFlags []*graphNode
+
+func (s nodeFlags) Visit(n *graphNode) bool {
+	if n.visited {
+		return false
+	}
+	n.visited = true
+	return true
+}
+
+func (s nodeFlags) Reset() {
+	for _, n := range s {
+		n.visited = false
+	}
+}
+
+// sliceQueue pops with q = q[1:] like the bfs functions: the backing array
+// keeps every popped element until append reallocates it.
+type sliceQueue[T any] struct {
+	items []T
+}
+
+func (q *sliceQueue[T]) Push(n T) { q.items = append(q.items, n) }
+
+func (q *sliceQueue[T]) Pop() (n T, ok bool) {
+	if len(q.items) == 0 {
+		return n, false
+	}
+	n = q.items[0]
+	q.items = q.items[1:]
+	return n, true
+}
+
+func (q *sliceQueue[T]) Reset() { q.items = nil }
+
+// ringQueue is a circular buffer that doubles when full, its size is bounded
+// by the widest frontier instead of the number of pushes.
+type ringQueue[T any] struct {
+	items      []T
+	head, size int
+}
+
+func (q *ringQueue[T]) Push(n T) {
+	if q.size == len(q.items) {
+		grown := make([]T, max(16, 2*len(q.items)))
+		k := copy(grown, q.items[q.head:])
+		copy(grown[k:], q.items[:q.head])
+		q.items, q.head = grown, 0
+	}
+	q.items[(q.head+q.size)&(len(q.items)-1)] = n
+	q.size++
+}
+
+func (q *ringQueue[T]) Pop() (n T, ok bool) {
+	if q.size == 0 {
+		return n, false
+	}
+	var zero T
+	n, q.items[q.head] = q.items[q.head], zero // drop the reference for the GC
+	q.head = (q.head + 1) & (len(q.items) - 1)
+	q.size--
+	return n, true
+}
+
+func (q *ringQueue[T]) Reset() {
+	clear(q.items)
+	q.head, q.size = 0, 0
+}
+
+// frontierQueue keeps the current and the next BFS level in two slices that
+// swap when the current one is drained, each reused level after level.
+type frontierQueue[T any] struct {
+	current, next []T
+	head          int
+}
+
+func (q *frontierQueue[T]) Push(n T) { q.next = append(q.next, n) }
+
+func (q *frontierQueue[T]) Pop() (n T, ok bool) {
+	if q.head == len(q.current) {
+		if len(q.next) == 0 {
+			return n, false
+		}
+		clear(q.current)
+		q.current, q.next = q.next, q.current[:0]
+		q.head = 0
+	}
+	n = q.current[q.head]
+	q.head++
+	return n, true
+}
+
+func (q *frontierQueue[T]) Reset() {
+	clear(q.current)
+	clear(q.next)
+	q.current, q.next = q.current[:0], q.next[:0]
+	q.head = 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/Elvis339/go_gc_eval/internal/matrix"
+)
+
+// make all
+// go run ./cmd/labrun experiments/graph-layouts.json
+func main() {
+	dryRun := flag.Bool("n", false, "Print the cells without running them")
+	out := flag.String("out", "", "Override the results root of the experiment file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <experiment.json>\n\nThe experiment file must be JSON, YAML is not supported.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := matrix.Load(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out != "" {
+		spec.Out = *out
+	}
+
+	if *dryRun {
+		for _, c := range spec.Cells() {
+			fmt.Printf("%s: %s %s %s\n", c.ID, strings.Join(c.Env, " "), c.Binary, strings.Join(c.Args, " "))
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	m, err := matrix.Run(ctx, spec, os.Stdout)
+	if m != nil {
+		fmt.Printf("Manifest: %s/manifest.json\n", m.Dir)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := 0
+	for _, c := range m.Cells {
+		for _, r := range c.Reps {
+			if r.Error != "" {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		log.Fatalf("%d runs failed, see the manifest", failed)
+	}
+}
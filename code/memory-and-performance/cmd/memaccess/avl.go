@@ -0,0 +1,252 @@
+package main
+
+// The balanced trees keep their height logarithmic whatever the input order,
+// so search depth no longer depends on luck. Insert and search are
+// iterative: a sorted input cannot grow the goroutine stack.
+
+// balancedTree is implemented by the AVL and red-black trees, pointer and
+// slab forms alike.
+type balancedTree interface {
+	insert(val int)
+	search(val int) bool
+	height() int
+}
+
+// maxHeight bounds the path an insert records. An AVL tree of height 64
+// holds more than 2^44 nodes, a red-black tree more than 2^32.
+const maxHeight = 64
+
+// avlNode is an AVL tree node, height is that of its subtree, 1 for a leaf.
+type avlNode struct {
+	value       int
+	left, right *avlNode
+	height      int32
+}
+
+type avlTree struct {
+	root *avlNode
+}
+
+func (n *avlNode) h() int32 {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *avlNode) fix() {
+	n.height = 1 + max(n.left.h(), n.right.h())
+}
+
+func (n *avlNode) rotateRight() *avlNode {
+	l := n.left
+	n.left, l.right = l.right, n
+	n.fix()
+	l.fix()
+	return l
+}
+
+func (n *avlNode) rotateLeft() *avlNode {
+	r := n.right
+	n.right, r.left = r.left, n
+	n.fix()
+	r.fix()
+	return r
+}
+
+// balance restores the AVL property at n and returns the subtree's new root.
+func (n *avlNode) balance() *avlNode {
+	n.fix()
+	switch bf := n.left.h() - n.right.h(); {
+	case bf > 1:
+		if n.left.left.h() < n.left.right.h() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case bf < -1:
+		if n.right.right.h() < n.right.left.h() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+// insert walks down recording the links it follows, then rebalances every
+// subtree on the way back up.
+func (t *avlTree) insert(val int) {
+	var path [maxHeight]**avlNode
+	depth := 0
+	link := &t.root
+	for *link != nil {
+		n := *link
+		if val == n.value {
+			return // Duplicate value
+		}
+		path[depth] = link
+		depth++
+		if val < n.value {
+			link = &n.left
+		} else {
+			link = &n.right
+		}
+	}
+
+	*link = &avlNode{value: val, height: 1}
+	for depth > 0 {
+		depth--
+		*path[depth] = (*path[depth]).balance()
+	}
+}
+
+func (t *avlTree) search(val int) bool {
+	n := t.root
+	for n != nil {
+		if val == n.value {
+			return true
+		}
+		if val < n.value {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return false
+}
+
+func (t *avlTree) height() int {
+	return int(t.root.h())
+}
+
+// avlSlabNode is avlNode linked by int32 indices into avlSlab.nodes, -1
+// meaning nil.
+type avlSlabNode struct {
+	value       int
+	left, right int32
+	height      int32
+}
+
+// avlSlab is avlTree with every node in one pointer-free slab.
+type avlSlab struct {
+	nodes []avlSlabNode
+	root  int32
+}
+
+func newAVLSlab(capacity int) *avlSlab {
+	return &avlSlab{
+		nodes: make([]avlSlabNode, 0, capacity),
+		root:  -1,
+	}
+}
+
+func (t *avlSlab) h(i int32) int32 {
+	if i < 0 {
+		return 0
+	}
+	return t.nodes[i].height
+}
+
+func (t *avlSlab) fix(i int32) {
+	n := &t.nodes[i]
+	n.height = 1 + max(t.h(n.left), t.h(n.right))
+}
+
+func (t *avlSlab) rotateRight(i int32) int32 {
+	l := t.nodes[i].left
+	t.nodes[i].left, t.nodes[l].right = t.nodes[l].right, i
+	t.fix(i)
+	t.fix(l)
+	return l
+}
+
+func (t *avlSlab) rotateLeft(i int32) int32 {
+	r := t.nodes[i].right
+	t.nodes[i].right, t.nodes[r].left = t.nodes[r].left, i
+	t.fix(i)
+	t.fix(r)
+	return r
+}
+
+func (t *avlSlab) balance(i int32) int32 {
+	t.fix(i)
+	n := &t.nodes[i]
+	switch bf := t.h(n.left) - t.h(n.right); {
+	case bf > 1:
+		if l := n.left; t.h(t.nodes[l].left) < t.h(t.nodes[l].right) {
+			n.left = t.rotateLeft(l)
+		}
+		return t.rotateRight(i)
+	case bf < -1:
+		if r := n.right; t.h(t.nodes[r].right) < t.h(t.nodes[r].left) {
+			n.right = t.rotateRight(r)
+		}
+		return t.rotateLeft(i)
+	}
+	return i
+}
+
+func (t *avlSlab) insert(val int) {
+	var path [maxHeight]int32
+	depth := 0
+	i := t.root
+	for i >= 0 {
+		n := &t.nodes[i]
+		if val == n.value {
+			return // Duplicate value
+		}
+		path[depth] = i
+		depth++
+		if val < n.value {
+			i = n.left
+		} else {
+			i = n.right
+		}
+	}
+
+	t.nodes = append(t.nodes, avlSlabNode{value: val, left: -1, right: -1, height: 1})
+	child := int32(len(t.nodes) - 1)
+	if depth == 0 {
+		t.root = child
+		return
+	}
+	if p := &t.nodes[path[depth-1]]; val < p.value {
+		p.left = child
+	} else {
+		p.right = child
+	}
+
+	// Rebalance bottom up, relinking each rebalanced subtree to its parent.
+	for depth > 0 {
+		depth--
+		i := path[depth]
+		r := t.balance(i)
+		switch {
+		case depth == 0:
+			t.root = r
+		case t.nodes[path[depth-1]].left == i:
+			t.nodes[path[depth-1]].left = r
+		default:
+			t.nodes[path[depth-1]].right = r
+		}
+	}
+}
+
+func (t *avlSlab) search(val int) bool {
+	i := t.root
+	for i >= 0 {
+		n := &t.nodes[i]
+		if val == n.value {
+			return true
+		}
+		if val < n.value {
+			i = n.left
+		} else {
+			i = n.right
+		}
+	}
+	return false
+}
+
+func (t *avlSlab) height() int {
+	return int(t.h(t.root))
+}
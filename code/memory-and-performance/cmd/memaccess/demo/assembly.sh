@@ -5,10 +5,12 @@ go build -o demo demo.go
 objdump -d demo > assembly.s
 
 grep -A 50 "main\\..*node.*search" assembly.s > ptr.s
+grep -A 50 "main\\..*slabBST.*searchAt" assembly.s > slab.s
 grep -A 50 "main\\..*contiguousBST.*search" assembly.s > array.s
 
 diff -u ptr.s array.s > ptr_array_diff.txt
+diff -u ptr.s slab.s > ptr_slab_diff.txt
 
 rm -rf *.s *.o demo
 
-echo "Assembly diff saved to ptr_array_diff.txt"
\ No newline at end of file
+echo "Assembly diffs saved to ptr_array_diff.txt and ptr_slab_diff.txt"
\ No newline at end of file
@@ -42,6 +42,50 @@ func (n *node) search(val int) bool {
 	return n.right.search(val)
 }
 
+type slabNode struct {
+	value       int   // 8 bytes at offset 0
+	left, right int32 // 4 bytes each at offsets 8 and 12, indices into the slab, -1 is nil
+}
+
+type slabBST struct {
+	nodes []slabNode
+	root  int32
+}
+
+//go:noinline
+func (t *slabBST) insertAt(i int32, val int) int32 {
+	if i < 0 {
+		// Appends to the slab instead of allocating a node on the heap
+		t.nodes = append(t.nodes, slabNode{value: val, left: -1, right: -1})
+		return int32(len(t.nodes) - 1)
+	}
+
+	if val < t.nodes[i].value {
+		left := t.insertAt(t.nodes[i].left, val)
+		t.nodes[i].left = left
+	} else if val > t.nodes[i].value {
+		right := t.insertAt(t.nodes[i].right, val)
+		t.nodes[i].right = right
+	}
+
+	return i
+}
+
+//go:noinline
+func (t *slabBST) searchAt(i int32, val int) bool {
+	if i < 0 {
+		return false
+	}
+	n := &t.nodes[i]
+	if val == n.value {
+		return true
+	}
+	if val < n.value {
+		return t.searchAt(n.left, val)
+	}
+	return t.searchAt(n.right, val)
+}
+
 type contiguousBST struct {
 	data []int
 	used []bool
@@ -109,6 +153,13 @@ func main() {
 	root = root.insert(7)
 	found1 := root.search(3)
 
+	// Slab BST usage
+	slab := &slabBST{root: -1}
+	slab.root = slab.insertAt(slab.root, 5)
+	slab.root = slab.insertAt(slab.root, 3)
+	slab.root = slab.insertAt(slab.root, 7)
+	found3 := slab.searchAt(slab.root, 3)
+
 	// Array BST usage
 	bst := &contiguousBST{
 		data: make([]int, 100),
@@ -120,7 +171,7 @@ func main() {
 	bst.insert(7)
 	found2 := bst.search(3)
 
-	if found1 && found2 {
+	if found1 && found2 && found3 {
 		fmt.Println("found")
 	}
 }
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math/bits"
+	"runtime/metrics"
+	"slices"
+	"unsafe"
+)
+
+// node and contiguousBST hold ints, which contain no pointers: the GC never
+// looks inside a value. The generic trees below take the key type and its
+// comparator as parameters so the same trees can hold keys the GC has to
+// scan and follow:
+//
+//   - int:    pointer-free, the generic counterpart of the int trees
+//   - string: a pointer to the bytes per key, each key its own allocation
+//   - ptr:    ptrKey, an int ordered by value with a pointer to its label
+type keyKind string
+
+const (
+	intKeys    keyKind = "int"
+	stringKeys keyKind = "string"
+	ptrKeys    keyKind = "ptr"
+)
+
+var keyKinds = []keyKind{intKeys, stringKeys, ptrKeys}
+
+func parseKeyKind(s string) (keyKind, error) {
+	if k := keyKind(s); slices.Contains(keyKinds, k) {
+		return k, nil
+	}
+	return "", fmt.Errorf("unknown key type %q, want int, string or ptr", s)
+}
+
+// ptrKey is ordered by value alone, label only gives the GC a pointer to
+// follow from every key.
+type ptrKey struct {
+	value int
+	label *string
+}
+
+func comparePtrKey(a, b ptrKey) int { return cmp.Compare(a.value, b.value) }
+
+// stringKey zero pads v so string order is numeric order: a tree of string
+// keys has the same shape as a tree of the ints they came from.
+func stringKey(v int) string { return fmt.Sprintf("%08d", v) }
+
+func newPtrKey(v int) ptrKey {
+	label := stringKey(v)
+	return ptrKey{value: v, label: &label}
+}
+
+// keysOf converts every value with key, one allocation per value for the
+// pointer-holding key types.
+func keysOf[K any](values []int, key func(int) K) []K {
+	keys := make([]K, len(values))
+	for i, v := range values {
+		keys[i] = key(v)
+	}
+	return keys
+}
+
+// genericNode is node with a key of type K.
+type genericNode[K any] struct {
+	key         K
+	left, right *genericNode[K]
+}
+
+// genericBST is the pointer tree of node over keys ordered by cmp.
+type genericBST[K any] struct {
+	root *genericNode[K]
+	cmp  func(a, b K) int
+}
+
+func newGenericBST[K any](cmp func(a, b K) int) *genericBST[K] {
+	return &genericBST[K]{cmp: cmp}
+}
+
+func (t *genericBST[K]) insert(key K) {
+	t.root = t.insertAt(t.root, key)
+}
+
+// insertAt mirrors node.insert and returns the subtree's root.
+func (t *genericBST[K]) insertAt(n *genericNode[K], key K) *genericNode[K] {
+	if n == nil {
+		return &genericNode[K]{key: key}
+	}
+
+	if c := t.cmp(key, n.key); c < 0 {
+		n.left = t.insertAt(n.left, key)
+	} else if c > 0 {
+		n.right = t.insertAt(n.right, key)
+	}
+	return n
+}
+
+func (t *genericBST[K]) search(key K) bool {
+	n := t.root
+	for n != nil {
+		c := t.cmp(key, n.key)
+		if c == 0 {
+			return true
+		}
+		if c < 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return false
+}
+
+func (t *genericBST[K]) height() int {
+	type entry struct {
+		n     *genericNode[K]
+		depth int
+	}
+	height := 0
+	stack := []entry{{t.root, 1}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.n == nil {
+			continue
+		}
+		height = max(height, e.depth)
+		stack = append(stack, entry{e.n.left, e.depth + 1}, entry{e.n.right, e.depth + 1})
+	}
+	return height
+}
+
+// genericContiguousBST is contiguousBST over keys ordered by cmp. It grows
+// and rebalances the same way; there is no fixed mode.
+type genericContiguousBST[K any] struct {
+	data []K
+	used []bool
+	size int
+	cmp  func(a, b K) int
+
+	maxDepth   int
+	grows      int
+	rebalances int
+	rebuilt    int
+}
+
+func newGenericContiguousBST[K any](size int, cmp func(a, b K) int) *genericContiguousBST[K] {
+	return &genericContiguousBST[K]{
+		data: make([]K, size),
+		used: make([]bool, size),
+		cmp:  cmp,
+	}
+}
+
+func (t *genericContiguousBST[K]) insert(key K) {
+	if len(t.data) == 0 {
+		t.grow()
+	}
+
+	index, depth := 0, 0
+	for {
+		if index >= len(t.data) {
+			if 2*len(t.data)+1 > maxSlack*(t.size+1) {
+				t.rebalance(index, key)
+				return
+			}
+			t.grow()
+		}
+
+		if !t.used[index] {
+			t.used[index] = true
+			t.data[index] = key
+			t.size++
+			t.maxDepth = max(t.maxDepth, depth)
+			return
+		}
+
+		c := t.cmp(key, t.data[index])
+		if c == 0 {
+			return // Duplicate key
+		}
+		if c < 0 {
+			index = 2*index + 1
+		} else {
+			index = 2*index + 2
+		}
+		depth++
+	}
+}
+
+func (t *genericContiguousBST[K]) grow() {
+	n := 2*len(t.data) + 1
+	t.data = append(t.data, make([]K, n-len(t.data))...)
+	t.used = append(t.used, make([]bool, n-len(t.used))...)
+	t.grows++
+}
+
+// rebalance mirrors contiguousBST.rebalance.
+func (t *genericContiguousBST[K]) rebalance(index int, key K) {
+	levels := bits.Len(uint(len(t.data)))
+	var sorted []K
+	root := index
+	for {
+		root = (root - 1) / 2
+		sorted = t.inorder(root, sorted[:0])
+		height := levels - (bits.Len(uint(root+1)) - 1)
+		threshold := 1 - 0.5*float64(height-1)/float64(max(1, levels-1))
+		if float64(len(sorted)+1) <= threshold*float64(t.slots(root)) {
+			break
+		}
+		if root == 0 {
+			t.grow()
+			break
+		}
+	}
+	i, _ := slices.BinarySearchFunc(sorted, key, t.cmp)
+	sorted = slices.Insert(sorted, i, key)
+
+	t.clearSubtree(root)
+	t.fill(sorted, root, bits.Len(uint(root+1))-1)
+	t.size++
+	t.rebalances++
+	t.rebuilt += len(sorted)
+}
+
+func (t *genericContiguousBST[K]) inorder(index int, out []K) []K {
+	if index >= len(t.data) || !t.used[index] {
+		return out
+	}
+	out = t.inorder(2*index+1, out)
+	out = append(out, t.data[index])
+	return t.inorder(2*index+2, out)
+}
+
+func (t *genericContiguousBST[K]) slots(root int) int {
+	n := 0
+	for first, width := root, 1; first < len(t.data); first, width = 2*first+1, 2*width {
+		n += min(width, len(t.data)-first)
+	}
+	return n
+}
+
+// clearSubtree also zeroes the keys, so the GC does not follow stale ones.
+func (t *genericContiguousBST[K]) clearSubtree(root int) {
+	for first, width := root, 1; first < len(t.data); first, width = 2*first+1, 2*width {
+		end := min(first+width, len(t.data))
+		clear(t.data[first:end])
+		clear(t.used[first:end])
+	}
+}
+
+func (t *genericContiguousBST[K]) fill(sorted []K, index, depth int) {
+	if len(sorted) == 0 {
+		return
+	}
+	left, right := t.slots(2*index+1), t.slots(2*index+2)
+	n := (len(sorted) - 1) * left / max(1, left+right)
+	n = max(n, len(sorted)-1-right)
+	t.data[index], t.used[index] = sorted[n], true
+	t.maxDepth = max(t.maxDepth, depth)
+	t.fill(sorted[:n], 2*index+1, depth+1)
+	t.fill(sorted[n+1:], 2*index+2, depth+1)
+}
+
+func (t *genericContiguousBST[K]) search(key K) bool {
+	index := 0
+	for index < len(t.data) && t.used[index] {
+		c := t.cmp(key, t.data[index])
+		if c == 0 {
+			return true
+		}
+		if c < 0 {
+			index = 2*index + 1
+		} else {
+			index = 2*index + 2
+		}
+	}
+	return false
+}
+
+func (t *genericContiguousBST[K]) stats() bstStats {
+	var zero K
+	st := bstStats{
+		Stored:      t.size,
+		MaxDepth:    t.maxDepth,
+		Slots:       len(t.data),
+		BytesWasted: (len(t.data) - t.size) * int(unsafe.Sizeof(zero)+unsafe.Sizeof(false)),
+		Grows:       t.grows,
+		Rebalances:  t.rebalances,
+		Rebuilt:     t.rebuilt,
+	}
+	if st.Slots > 0 {
+		st.Occupancy = float64(st.Stored) / float64(st.Slots)
+	}
+	return st
+}
+
+// keyResult is what a generic run reports besides its duration.
+type keyResult struct {
+	found  int
+	height func() int
+	stats  *bstStats
+	live   any // the tree, kept reachable until the scan size is read
+}
+
+// runKeys builds the pointer tree (ptr) or the contiguous tree (array) from
+// keys and counts the searches that hit.
+func runKeys[K any](version string, size int, keys, searches []K, cmp func(a, b K) int) keyResult {
+	var res keyResult
+	if version == "ptr" {
+		t := newGenericBST(cmp)
+		for _, key := range keys {
+			t.insert(key)
+		}
+		for _, search := range searches {
+			if t.search(search) {
+				res.found++
+			}
+		}
+		res.height, res.live = t.height, t
+		return res
+	}
+
+	t := newGenericContiguousBST(size, cmp)
+	for _, key := range keys {
+		t.insert(key)
+	}
+	for _, search := range searches {
+		if t.search(search) {
+			res.found++
+		}
+	}
+	st := t.stats()
+	res.stats, res.live = &st, t
+	return res
+}
+
+// gcWork is the GC's marking cost and the scannable heap, read from
+// runtime/metrics.
+type gcWork struct {
+	assist, dedicated, idle float64 // mark CPU seconds per worker class
+	scanHeap                uint64  // scannable heap bytes, a gauge
+}
+
+var gcWorkMetrics = []metrics.Sample{
+	{Name: "/cpu/classes/gc/mark/assist:cpu-seconds"},
+	{Name: "/cpu/classes/gc/mark/dedicated:cpu-seconds"},
+	{Name: "/cpu/classes/gc/mark/idle:cpu-seconds"},
+	{Name: "/gc/scan/heap:bytes"},
+}
+
+func readGCWork() gcWork {
+	samples := slices.Clone(gcWorkMetrics)
+	metrics.Read(samples)
+
+	return gcWork{
+		assist:    samples[0].Value.Float64(),
+		dedicated: samples[1].Value.Float64(),
+		idle:      samples[2].Value.Float64(),
+		scanHeap:  samples[3].Value.Uint64(),
+	}
+}
+
+// sub returns the mark CPU spent since v, scanHeap stays w's.
+func (w gcWork) sub(v gcWork) gcWork {
+	return gcWork{
+		assist:    w.assist - v.assist,
+		dedicated: w.dedicated - v.dedicated,
+		idle:      w.idle - v.idle,
+		scanHeap:  w.scanHeap,
+	}
+}
+
+func (w gcWork) mark() float64 {
+	return w.assist + w.dedicated + w.idle
+}
+
+func (w gcWork) String() string {
+	return fmt.Sprintf("gc-mark=%.1fms (assist %.1fms, dedicated %.1fms, idle %.1fms) scan-heap=%.1fMB",
+		1000*w.mark(), 1000*w.assist, 1000*w.dedicated, 1000*w.idle, float64(w.scanHeap)/(1<<20))
+}
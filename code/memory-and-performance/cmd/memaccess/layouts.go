@@ -0,0 +1,214 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// The layouts below hold the same values as a static, perfectly balanced
+// search tree, so unlike contiguousBST they do not depend on insertion
+// order. They differ only in where each tree node lives in memory:
+//
+//   - eytzinger: BFS order in one array, the top levels share cache lines
+//   - vebTree:   van Emde Boas order, every subtree of height h is packed
+//     into 2^h-1 consecutive slots, at every scale
+//   - bTree:     8 keys per node, one cache line per level instead of one
+//     per comparison
+
+// sortedUnique returns values sorted without duplicates, the contents every
+// static layout is built from.
+func sortedUnique(values []int) []int {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// eytzinger stores a complete binary search tree in BFS order: the children
+// of index i are 2*i+1 and 2*i+2, like contiguousBST, but the tree is
+// balanced and the array has no holes.
+type eytzinger []int
+
+func newEytzinger(values []int) eytzinger {
+	sorted := sortedUnique(values)
+	e := make(eytzinger, len(sorted))
+	e.fill(sorted, 0, 0)
+	return e
+}
+
+// fill assigns sorted values by an in-order walk of the implicit tree, which
+// is what makes it a search tree. k is the next value, the new k is returned.
+func (e eytzinger) fill(sorted []int, i, k int) int {
+	if i >= len(e) {
+		return k
+	}
+	k = e.fill(sorted, 2*i+1, k)
+	e[i] = sorted[k]
+	k++
+	return e.fill(sorted, 2*i+2, k)
+}
+
+func (e eytzinger) search(val int) bool {
+	index := 0
+	for index < len(e) {
+		if val == e[index] {
+			return true
+		}
+		if val < e[index] {
+			index = 2*index + 1
+		} else {
+			index = 2*index + 2
+		}
+	}
+	return false
+}
+
+// vebTree stores a complete binary search tree of height h in van Emde Boas
+// order: the top half of the levels first, then each bottom subtree, every
+// part laid out the same way recursively. Unused slots of the complete tree
+// hold math.MaxInt, which the values never reach.
+type vebTree struct {
+	data   []int
+	height int
+
+	// Per depth d > 0: depth d is the root level of the bottom trees of
+	// exactly one split. top[d] is the size of that split's top tree,
+	// bottom[d] the size of one bottom tree and topDepth[d] the depth of
+	// the top tree's root.
+	top, bottom, topDepth []int
+}
+
+func newVEBTree(values []int) *vebTree {
+	sorted := sortedUnique(values)
+	height := 1
+	for 1<<height-1 < len(sorted) {
+		height++
+	}
+	t := &vebTree{
+		data:     make([]int, 1<<height-1),
+		height:   height,
+		top:      make([]int, height),
+		bottom:   make([]int, height),
+		topDepth: make([]int, height),
+	}
+	t.split(0, height)
+
+	// Lay out the complete tree in BFS order first, then move every node to
+	// its van Emde Boas slot.
+	bfs := make(eytzinger, len(t.data))
+	for i := range bfs {
+		bfs[i] = math.MaxInt
+	}
+	padded := append(slices.Clone(sorted), bfs[len(sorted):]...)
+	bfs.fill(padded, 0, 0)
+	for pos, i := range t.order(1, height, make([]int, 0, len(t.data))) {
+		t.data[pos] = bfs[i-1]
+	}
+	return t
+}
+
+// split records the tables for the subtree of the given height whose root
+// is at depth.
+func (t *vebTree) split(depth, height int) {
+	if height == 1 {
+		return
+	}
+	top := height / 2
+	bottom := height - top
+	d := depth + top
+	t.topDepth[d] = depth
+	t.top[d] = 1<<top - 1
+	t.bottom[d] = 1<<bottom - 1
+	t.split(depth, top)
+	t.split(d, bottom)
+}
+
+// order appends the 1-based BFS indices of the subtree rooted at i in van
+// Emde Boas order, splitting the same way as split.
+func (t *vebTree) order(i, height int, out []int) []int {
+	if height == 1 {
+		return append(out, i)
+	}
+	top := height / 2
+	out = t.order(i, top, out)
+	for j := i << top; j < (i+1)<<top; j++ {
+		out = t.order(j, height-top, out)
+	}
+	return out
+}
+
+// search walks the tree by BFS index and computes each node's slot from the
+// slot of its top tree's root (Brodal, Fagerberg and Jacob): no pointers and
+// no stored child positions.
+func (t *vebTree) search(val int) bool {
+	var pos [64]int // slot of the node visited at each depth
+	i := 1          // 1-based BFS index
+	for d := 0; d < t.height; d++ {
+		if d > 0 {
+			pos[d] = pos[t.topDepth[d]] + t.top[d] + (i&t.top[d])*t.bottom[d]
+		}
+		v := t.data[pos[d]]
+		if val == v {
+			return true
+		}
+		if val < v {
+			i = 2 * i
+		} else {
+			i = 2*i + 1
+		}
+	}
+	return false
+}
+
+// btreeKeys fills a 64-byte cache line with ints.
+const btreeKeys = 8
+
+type btreeNode [btreeKeys]int
+
+// bTree is a static B-tree with implicit children: node k's children are
+// k*(btreeKeys+1)+1 through k*(btreeKeys+1)+btreeKeys+1. A search compares
+// against a whole cache line before it moves on. Unused keys hold
+// math.MaxInt.
+type bTree struct {
+	nodes []btreeNode
+}
+
+func newBTree(values []int) *bTree {
+	sorted := sortedUnique(values)
+	t := &bTree{nodes: make([]btreeNode, (len(sorted)+btreeKeys-1)/btreeKeys)}
+	t.fill(sorted, 0, 0)
+	return t
+}
+
+// fill assigns sorted values in key order: child 0, key 0, child 1, key 1,
+// ..., child btreeKeys.
+func (t *bTree) fill(sorted []int, k, next int) int {
+	if k >= len(t.nodes) {
+		return next
+	}
+	for i := 0; i < btreeKeys; i++ {
+		next = t.fill(sorted, k*(btreeKeys+1)+i+1, next)
+		if next < len(sorted) {
+			t.nodes[k][i] = sorted[next]
+			next++
+		} else {
+			t.nodes[k][i] = math.MaxInt
+		}
+	}
+	return t.fill(sorted, k*(btreeKeys+1)+btreeKeys+1, next)
+}
+
+func (t *bTree) search(val int) bool {
+	k := 0
+	for k < len(t.nodes) {
+		n := &t.nodes[k]
+		i := 0
+		for i < btreeKeys && n[i] < val {
+			i++
+		}
+		if i < btreeKeys && n[i] == val {
+			return true
+		}
+		k = k*(btreeKeys+1) + i + 1
+	}
+	return false
+}
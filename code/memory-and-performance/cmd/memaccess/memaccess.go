@@ -1,20 +1,18 @@
 package main
 
 import (
+	"cmp"
 	"flag"
 	"fmt"
-	"github.com/arl/statsviz"
 	"log"
+	"math/bits"
 	"math/rand"
-	"net"
-	"net/http"
-	"os"
-	"os/signal"
-	"path/filepath"
 	"runtime"
-	"runtime/pprof"
-	"syscall"
-	"time"
+	"slices"
+	"strings"
+	"unsafe"
+
+	"github.com/Elvis339/go_gc_eval/internal/harness"
 )
 
 // node represents a traditional pointer-based binary search tree (BST)
@@ -63,6 +61,27 @@ func (n *node) search(val int) bool {
 	return n.right.search(val)
 }
 
+// height returns the number of levels below and including n. It uses an
+// explicit stack: on sorted input the tree is a list as deep as the input.
+func (n *node) height() int {
+	type entry struct {
+		n     *node
+		depth int
+	}
+	height := 0
+	stack := []entry{{n, 1}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.n == nil {
+			continue
+		}
+		height = max(height, e.depth)
+		stack = append(stack, entry{e.n.left, e.depth + 1}, entry{e.n.right, e.depth + 1})
+	}
+	return height
+}
+
 // contiguousBST implements BST using array-based heap indexing
 // All data stored in contiguous memory for better spatial locality
 // Uses heap property: parent at i, left child at 2*i+1, right child at 2*i+2
@@ -70,8 +89,23 @@ type contiguousBST struct {
 	data []int  // Contiguous array storing all values
 	used []bool // Tracks which array positions are occupied
 	size int    // Current number of elements
+
+	// fixed keeps the original behaviour: an insert that runs off the end of
+	// the array is dropped instead of growing or rebalancing the tree.
+	fixed      bool
+	dropped    int
+	maxDepth   int
+	grows      int
+	rebalances int
+	rebuilt    int
 }
 
+// maxSlack bounds the slots per stored value growth may leave behind. An
+// insert past the end of the array adds a level when that keeps the array
+// below maxSlack slots per value, and rebalances a subtree otherwise: random
+// input makes paths far deeper than log2(n) and each level doubles the array.
+const maxSlack = 8
+
 func newContiguousBST(size int) *contiguousBST {
 	return &contiguousBST{
 		data: make([]int, size),
@@ -80,29 +114,41 @@ func newContiguousBST(size int) *contiguousBST {
 	}
 }
 
+// newFixedContiguousBST returns a tree that never grows and drops inserts it
+// has no slot for.
+func newFixedContiguousBST(size int) *contiguousBST {
+	cbst := newContiguousBST(size)
+	cbst.fixed = true
+	return cbst
+}
+
 // insert adds value using array indexing instead of pointer following
 // Uses arithmetic (2*i+1, 2*i+2) instead of pointer dereferencing
 // Accesses predictable memory locations - cache-friendly
 func (cbst *contiguousBST) insert(val int) {
-	if cbst.size == 0 {
-		// First element goes at root (index 0)
-		cbst.data[0] = val
-		cbst.used[0] = true
-		cbst.size++
-		return
+	if len(cbst.data) == 0 {
+		cbst.grow()
 	}
 
-	index := 0
-	l := len(cbst.data)
+	index, depth := 0, 0
 	for {
-		if index >= l {
-			return
+		if index >= len(cbst.data) {
+			if cbst.fixed {
+				cbst.dropped++
+				return
+			}
+			if 2*len(cbst.data)+1 > maxSlack*(cbst.size+1) {
+				cbst.rebalance(index, val)
+				return
+			}
+			cbst.grow()
 		}
 
 		if !cbst.used[index] {
 			cbst.used[index] = true
 			cbst.data[index] = val
 			cbst.size++
+			cbst.maxDepth = max(cbst.maxDepth, depth)
 			return
 		}
 
@@ -114,9 +160,134 @@ func (cbst *contiguousBST) insert(val int) {
 		} else {
 			return // Duplicate value
 		}
+		depth++
 	}
 }
 
+// grow adds one level to the array: 2n+1 slots hold every child of the
+// current last level.
+func (cbst *contiguousBST) grow() {
+	n := 2*len(cbst.data) + 1
+	cbst.data = append(cbst.data, make([]int, n-len(cbst.data))...)
+	cbst.used = append(cbst.used, make([]bool, n-len(cbst.used))...)
+	cbst.grows++
+}
+
+// rebalance stores val, whose insert ran off the end of the array at index,
+// by rebuilding the lowest subtree on its path that stays below its density
+// threshold with val added, as a packed-memory array does. Thresholds fall
+// from 1 for the leaves to 1/2 for the root, so a rebuilt subtree takes many
+// inserts to go over again and sorted input costs O(log² n) amortised per
+// insert instead of rebuilding the whole tree every few inserts. A tree over
+// the root's threshold grows.
+func (cbst *contiguousBST) rebalance(index, val int) {
+	levels := bits.Len(uint(len(cbst.data)))
+	var sorted []int
+	root := index
+	for {
+		root = (root - 1) / 2
+		sorted = cbst.inorder(root, sorted[:0])
+		height := levels - (bits.Len(uint(root+1)) - 1)
+		threshold := 1 - 0.5*float64(height-1)/float64(max(1, levels-1))
+		if float64(len(sorted)+1) <= threshold*float64(cbst.slots(root)) {
+			break
+		}
+		if root == 0 {
+			cbst.grow()
+			break
+		}
+	}
+	i, _ := slices.BinarySearch(sorted, val)
+	sorted = slices.Insert(sorted, i, val)
+
+	cbst.clearSubtree(root)
+	cbst.fill(sorted, root, bits.Len(uint(root+1))-1)
+	cbst.size++
+	cbst.rebalances++
+	cbst.rebuilt += len(sorted)
+}
+
+// inorder appends the values under index in sorted order.
+func (cbst *contiguousBST) inorder(index int, out []int) []int {
+	if index >= len(cbst.data) || !cbst.used[index] {
+		return out
+	}
+	out = cbst.inorder(2*index+1, out)
+	out = append(out, cbst.data[index])
+	return cbst.inorder(2*index+2, out)
+}
+
+// slots counts the slots of the subtree at root that fit in the array. Each
+// level of the subtree is a contiguous run of the array, the last one may be
+// cut short.
+func (cbst *contiguousBST) slots(root int) int {
+	n := 0
+	for first, width := root, 1; first < len(cbst.data); first, width = 2*first+1, 2*width {
+		n += min(width, len(cbst.data)-first)
+	}
+	return n
+}
+
+func (cbst *contiguousBST) clearSubtree(root int) {
+	for first, width := root, 1; first < len(cbst.data); first, width = 2*first+1, 2*width {
+		end := min(first+width, len(cbst.data))
+		clear(cbst.data[first:end])
+		clear(cbst.used[first:end])
+	}
+}
+
+// fill places sorted values into the subtree at index by an in-order walk,
+// which keeps the search tree order. Each child gets values in proportion to
+// its slots, so both come out as full as the subtree: the array's last level
+// may be cut short and a complete tree would fill the right child up.
+func (cbst *contiguousBST) fill(sorted []int, index, depth int) {
+	if len(sorted) == 0 {
+		return
+	}
+	left, right := cbst.slots(2*index+1), cbst.slots(2*index+2)
+	n := (len(sorted) - 1) * left / max(1, left+right)
+	n = max(n, len(sorted)-1-right)
+	cbst.data[index], cbst.used[index] = sorted[n], true
+	cbst.maxDepth = max(cbst.maxDepth, depth)
+	cbst.fill(sorted[:n], 2*index+1, depth+1)
+	cbst.fill(sorted[n+1:], 2*index+2, depth+1)
+}
+
+// bstStats describes how well a contiguousBST uses its arrays.
+type bstStats struct {
+	Stored      int     // values in the tree
+	Dropped     int     // inserts a fixed tree had no slot for
+	MaxDepth    int     // deepest slot ever filled, the root is 0
+	Slots       int     // length of the backing arrays
+	Occupancy   float64 // Stored / Slots
+	BytesWasted int     // data and used bytes of empty slots
+	Grows       int
+	Rebalances  int
+	Rebuilt     int // values placed by rebalances, their total cost
+}
+
+func (cbst *contiguousBST) stats() bstStats {
+	st := bstStats{
+		Stored:      cbst.size,
+		Dropped:     cbst.dropped,
+		MaxDepth:    cbst.maxDepth,
+		Slots:       len(cbst.data),
+		BytesWasted: (len(cbst.data) - cbst.size) * int(unsafe.Sizeof(int(0))+unsafe.Sizeof(false)),
+		Grows:       cbst.grows,
+		Rebalances:  cbst.rebalances,
+		Rebuilt:     cbst.rebuilt,
+	}
+	if st.Slots > 0 {
+		st.Occupancy = float64(st.Stored) / float64(st.Slots)
+	}
+	return st
+}
+
+func (st bstStats) String() string {
+	return fmt.Sprintf("stored=%d dropped=%d max-depth=%d slots=%d occupancy=%.1f%% wasted=%.1fMB grows=%d rebalances=%d rebuilt=%d",
+		st.Stored, st.Dropped, st.MaxDepth, st.Slots, 100*st.Occupancy, float64(st.BytesWasted)/(1<<20), st.Grows, st.Rebalances, st.Rebuilt)
+}
+
 // search traverses BST using array indexing instead of pointer chasing
 // Each access is to predictable memory location within contiguous array
 // Much more cache-friendly than random pointer dereferencing
@@ -151,131 +322,198 @@ func setup(n int) ([]int, []int) {
 	return values, searches
 }
 
-func getExecutableName() string {
-	executable, err := os.Executable()
-	if err != nil {
-		return "unknown"
-	}
-	return filepath.Base(executable)
-}
+// Usage examples:
+// go run . -v ptr -s 1000000 -p          # Profile pointer BST with 1M elements
+// go run . -v array -s 5000000           # Run array BST with 5M elements (no profiling)
+// go run . -v slab -s 5000000            # Pointer BST shape, nodes in one pointer-free slice
+// go run . -v btree -s 5000000           # Static B-tree with cache line sized nodes
+// go run . -v ptr -key string -s 1000000 # Generic pointer BST over string keys
+// go run . -v ptr -statsviz              # Pointer BST, 5M elements, live stats until Ctrl+C
+func main() {
+	opts := harness.RegisterFlags(flag.CommandLine)
+	version := flag.String("v", "ptr", "BST version: ptr (scattered), slab (index-linked nodes in one slice), avl, avl-slab, rb, rb-slab (balanced), array (contiguous), eytzinger, veb or btree (static layouts)")
+	treeSize := flag.Int("s", 5_000_000, "Number of elements to insert into the BST")
+	sorted := flag.Bool("sorted", false, "Insert the values in ascending order, the worst case for the unbalanced trees")
+	fixed := flag.Bool("fixed", false, "array: keep the original fixed-size tree that drops inserts running off the end")
+	keyType := flag.String("key", "", "ptr, array: run the generic tree over int, string or ptr (struct with a pointer) keys")
+	flag.Parse()
 
-func startStatsvizServer() string {
-	listener, err := net.Listen("tcp", ":0")
-	if err != nil {
-		log.Fatal("Failed to find available port:", err)
+	variant := *version
+	var key keyKind
+	if *keyType != "" {
+		var err error
+		if key, err = parseKeyKind(*keyType); err != nil {
+			log.Fatal(err)
+		}
+		if *version != "ptr" && *version != "array" {
+			log.Fatalf("-key needs -v ptr or -v array, not %q", *version)
+		}
+		variant += "-" + string(key)
 	}
-	p, ok := listener.Addr().(*net.TCPAddr)
-	if !ok {
-		log.Fatal("Failed to find available port")
+	if *sorted {
+		variant += "-sorted"
 	}
-	port := p.Port
-	_ = listener.Close()
-
-	err = statsviz.Register(http.DefaultServeMux)
+	h, err := harness.New(opts, variant)
 	if err != nil {
-		log.Fatal("Failed to register statsviz:", err)
+		log.Fatal(err)
 	}
 
-	go func() {
-		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
-	}()
-
-	return fmt.Sprintf("http://localhost:%d/debug/statsviz/", port)
-}
-
-func startProfiling(enable bool, execName string) func() {
-	if !enable {
-		return func() {}
+	values, searches := setup(*treeSize)
+	if *sorted {
+		slices.Sort(values)
 	}
-
-	// CPU profiling
-	cpuFile, err := os.Create(filepath.Join("traces", fmt.Sprintf("%s_cpu.pprof", execName)))
-	if err != nil {
-		log.Fatal("Failed to create CPU profile file:", err)
+	// Keys are converted before the measurement, the conversion allocates
+	// every string and label.
+	var (
+		stringValues, stringSearches []string
+		ptrValues, ptrSearches       []ptrKey
+	)
+	switch key {
+	case stringKeys:
+		stringValues, stringSearches = keysOf(values, stringKey), keysOf(searches, stringKey)
+	case ptrKeys:
+		ptrValues, ptrSearches = keysOf(values, newPtrKey), keysOf(searches, newPtrKey)
 	}
 
-	if err := pprof.StartCPUProfile(cpuFile); err != nil {
-		cpuFile.Close()
-		log.Fatal("Failed to start CPU profiling:", err)
-	}
-
-	// Return cleanup function
-	return func() {
-		pprof.StopCPUProfile()
-		cpuFile.Close()
-
-		// Memory profiling
-		runtime.GC()
-		memFile, err := os.Create(filepath.Join("traces", fmt.Sprintf("%s_mem.pprof", execName)))
-		if err != nil {
-			log.Printf("Failed to create memory profile file: %v", err)
+	// found counts the searches that hit, a tree that lost values finds
+	// fewer than the others.
+	var (
+		found  int
+		stats  *bstStats
+		height func() int // computed after the measurement
+		live   any        // the structure searched, reachable until the scan size is read
+	)
+	gcBefore := readGCWork()
+	duration, err := h.Measure(func() {
+		if key != "" {
+			var res keyResult
+			switch key {
+			case intKeys:
+				res = runKeys(*version, *treeSize, values, searches, cmp.Compare[int])
+			case stringKeys:
+				res = runKeys(*version, *treeSize, stringValues, stringSearches, strings.Compare)
+			default:
+				res = runKeys(*version, *treeSize, ptrValues, ptrSearches, comparePtrKey)
+			}
+			found, height, stats, live = res.found, res.height, res.stats, res.live
 			return
 		}
-		defer memFile.Close()
 
-		if err := pprof.WriteHeapProfile(memFile); err != nil {
-			log.Printf("Failed to write heap profile: %v", err)
-			return
+		switch *version {
+		case "ptr":
+			var root *node
+			for _, val := range values {
+				root = root.insert(val)
+				_ = root
+			}
+
+			for _, search := range searches {
+				if root.search(search) {
+					found++
+				}
+			}
+			height, live = root.height, root
+		case "slab":
+			t := newSlabBST(*treeSize)
+			for _, val := range values {
+				t.insert(val)
+			}
+
+			for _, search := range searches {
+				if t.search(search) {
+					found++
+				}
+			}
+			height, live = t.height, t
+		case "avl", "avl-slab", "rb", "rb-slab":
+			var t balancedTree
+			switch *version {
+			case "avl":
+				t = &avlTree{}
+			case "avl-slab":
+				t = newAVLSlab(*treeSize)
+			case "rb":
+				t = &rbTree{}
+			default:
+				t = newRBSlab(*treeSize)
+			}
+			for _, val := range values {
+				t.insert(val)
+			}
+
+			for _, search := range searches {
+				if t.search(search) {
+					found++
+				}
+			}
+			height, live = t.height, t
+		case "eytzinger":
+			e := newEytzinger(values)
+			live = e
+
+			for _, search := range searches {
+				if e.search(search) {
+					found++
+				}
+			}
+		case "veb":
+			t := newVEBTree(values)
+			live = t
+
+			for _, search := range searches {
+				if t.search(search) {
+					found++
+				}
+			}
+		case "btree":
+			t := newBTree(values)
+			live = t
+
+			for _, search := range searches {
+				if t.search(search) {
+					found++
+				}
+			}
+		default:
+			var cbst *contiguousBST
+			if *fixed {
+				cbst = newFixedContiguousBST(*treeSize * 2)
+			} else {
+				cbst = newContiguousBST(*treeSize)
+			}
+			for _, val := range values {
+				cbst.insert(val)
+			}
+
+			for _, search := range searches {
+				if cbst.search(search) {
+					found++
+				}
+			}
+			st := cbst.stats()
+			stats, live = &st, cbst
 		}
-
-		fmt.Printf("Generated: %s_cpu.pprof, %s_mem.pprof\n", execName, execName)
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
-}
-
-// Usage examples:
-// go run . -v ptr -s 1000000 -p          # Profile pointer BST with 1M elements
-// go run . -v -s 5000000           # Run array BST with 5M elements (no profiling)
-// go run . -v ptr                        # Default: pointer BST, 5M elements, no profiling
-func main() {
-	version := flag.String("v", "ptr", "BST version: ptr (scattered) or array (contiguous)")
-	treeSize := flag.Int("s", 5_000_000, "Number of elements to insert into the BST")
-	enableProfiling := flag.Bool("p", false, "Enable CPU and memory profiling")
-	flag.Parse()
-
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-
-	execName := getExecutableName()
-
-	// Start profiling if enabled
-	stopProfiling := startProfiling(*enableProfiling, execName)
-	defer stopProfiling()
-
-	// Start statsviz HTTP server
-	statsvizURL := startStatsvizServer()
-	fmt.Printf("statsviz server: %s\n", statsvizURL)
-
-	values, searches := setup(*treeSize)
-
-	start := time.Now()
-	switch *version {
-	case "ptr":
-		var root *node
-		for _, val := range values {
-			root = root.insert(val)
-			_ = root
-		}
-
-		s := false
-		for _, search := range searches {
-			s = root.search(search)
-			_ = s
-		}
-		fmt.Printf("BST(%s): %s size=%d\n", *version, time.Since(start), *treeSize)
-	default:
-		cbst := newContiguousBST(*treeSize * 2)
-		for _, val := range values {
-			cbst.insert(val)
-		}
-
-		s := false
-		for _, search := range searches {
-			s = cbst.search(search)
-			_ = s
-		}
-		fmt.Printf("BST(%s): %s size=%d\n", *version, time.Since(start), *treeSize)
+	gc := readGCWork().sub(gcBefore)
+	// The scannable heap is a gauge updated by each cycle: a forced one
+	// while the structure is still reachable counts it.
+	runtime.GC()
+	gc.scanHeap = readGCWork().scanHeap
+	runtime.KeepAlive(live)
+
+	fmt.Printf("BST(%s): %s size=%d found=%d\n", variant, duration, *treeSize, found)
+	fmt.Printf("  %s\n", gc)
+	if height != nil {
+		fmt.Printf("  height=%d\n", height())
+	}
+	if stats != nil {
+		fmt.Printf("  %s\n", stats)
 	}
 
-	fmt.Println("Press Ctrl+C to stop the server")
-	<-stop
+	if err := h.Close(); err != nil {
+		log.Fatal(err)
+	}
 }
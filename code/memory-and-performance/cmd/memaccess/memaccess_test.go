@@ -1,16 +1,25 @@
 package main
 
 import (
+	"cmp"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 )
 
 // go test -bench=BenchmarkPointerBST -memprofile=mem.prof
+// go test -bench=BenchmarkSlabBST -memprofile=mem.prof
 // go test -bench=BenchmarkContiguousBST -memprofile=mem.prof
 
 // go tool pprof mem.prof
 // top10
 
 // go test -bench=. -benchmem -count=6
+
+const treeSize = 1_000_000
+
 func BenchmarkPointerBST(b *testing.B) {
 	values, searches := setup(treeSize)
 	b.ResetTimer()
@@ -29,6 +38,24 @@ func BenchmarkPointerBST(b *testing.B) {
 	}
 }
 
+func BenchmarkSlabBST(b *testing.B) {
+	values, searches := setup(treeSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		t := newSlabBST(treeSize)
+		for _, val := range values {
+			t.insert(val)
+		}
+
+		s := false
+		for _, search := range searches {
+			s = t.search(search)
+		}
+		_ = s
+	}
+}
+
 func BenchmarkContiguousBST(b *testing.B) {
 	values, searches := setup(treeSize)
 	b.ResetTimer()
@@ -46,3 +73,398 @@ func BenchmarkContiguousBST(b *testing.B) {
 		_ = s
 	}
 }
+
+// The original tree, fixed at twice the input size and dropping the inserts
+// that run off its end.
+func BenchmarkContiguousBSTFixed(b *testing.B) {
+	values, searches := setup(treeSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cbst := newFixedContiguousBST(treeSize * 2)
+		for _, val := range values {
+			cbst.insert(val)
+		}
+
+		s := false
+		for _, search := range searches {
+			s = cbst.search(search)
+		}
+		_ = s
+	}
+}
+
+func BenchmarkEytzinger(b *testing.B) {
+	values, searches := setup(treeSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		e := newEytzinger(values)
+
+		s := false
+		for _, search := range searches {
+			s = e.search(search)
+		}
+		_ = s
+	}
+}
+
+func BenchmarkVEB(b *testing.B) {
+	values, searches := setup(treeSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		t := newVEBTree(values)
+
+		s := false
+		for _, search := range searches {
+			s = t.search(search)
+		}
+		_ = s
+	}
+}
+
+func BenchmarkBTree(b *testing.B) {
+	values, searches := setup(treeSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		t := newBTree(values)
+
+		s := false
+		for _, search := range searches {
+			s = t.search(search)
+		}
+		_ = s
+	}
+}
+
+func TestLayoutsMatchPointerBST(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 8, 9, 100, 10_000} {
+		values, searches := setup(n)
+		var root *node
+		for _, val := range values {
+			root = root.insert(val)
+		}
+		e, veb, bt := newEytzinger(values), newVEBTree(values), newBTree(values)
+		slab := newSlabBST(0)
+		for _, val := range values {
+			slab.insert(val)
+		}
+
+		for _, val := range append(searches, values...) {
+			want := root.search(val)
+			if got := slab.search(val); got != want {
+				t.Errorf("n=%d: slab search(%d) = %t, want %t", n, val, got, want)
+			}
+			if got := e.search(val); got != want {
+				t.Errorf("n=%d: eytzinger search(%d) = %t, want %t", n, val, got, want)
+			}
+			if got := veb.search(val); got != want {
+				t.Errorf("n=%d: veb search(%d) = %t, want %t", n, val, got, want)
+			}
+			if got := bt.search(val); got != want {
+				t.Errorf("n=%d: btree search(%d) = %t, want %t", n, val, got, want)
+			}
+		}
+	}
+}
+
+func TestContiguousBSTStoresEverything(t *testing.T) {
+	values, searches := setup(200_000)
+	var root *node
+	for _, val := range values {
+		root = root.insert(val)
+	}
+	unique := len(sortedUnique(values))
+
+	for _, size := range []int{0, 1, 1000} {
+		cbst := newContiguousBST(size)
+		for _, val := range values {
+			cbst.insert(val)
+		}
+		st := cbst.stats()
+		if st.Stored != unique || st.Dropped != 0 {
+			t.Fatalf("size %d: %s, want %d stored", size, st, unique)
+		}
+		if st.Slots > maxSlack*(unique+1) {
+			t.Errorf("size %d: %d slots for %d values", size, st.Slots, unique)
+		}
+		for _, val := range append(searches, values...) {
+			if got, want := cbst.search(val), root.search(val); got != want {
+				t.Fatalf("size %d: search(%d) = %t, want %t", size, val, got, want)
+			}
+		}
+	}
+
+	fixed := newFixedContiguousBST(1000)
+	for _, val := range values {
+		fixed.insert(val)
+	}
+	if st := fixed.stats(); st.Dropped == 0 || st.Stored+st.Dropped > len(values) || st.Slots != 1000 {
+		t.Errorf("fixed: %s", st)
+	}
+}
+
+func TestContiguousBSTSortedInput(t *testing.T) {
+	const n = 50_000
+	for _, size := range []int{0, n} {
+		cbst := newContiguousBST(size)
+		for val := 0; val < n; val++ {
+			cbst.insert(val)
+		}
+		st := cbst.stats()
+		// Every insert rebuilds a small subtree at most once. Whole-tree
+		// rebuilds every few inserts would move O(n²) values.
+		logN := math.Log2(n)
+		if st.Stored != n || st.Rebalances > n || float64(st.Rebuilt) > n*logN*logN/2 {
+			t.Fatalf("size %d: %s", size, st)
+		}
+		if st.Slots > maxSlack*(n+1) {
+			t.Errorf("size %d: %d slots for %d values", size, st.Slots, n)
+		}
+		for val := -1; val <= n; val++ {
+			if got := cbst.search(val); got != (val >= 0 && val < n) {
+				t.Fatalf("size %d: search(%d) = %t", size, val, got)
+			}
+		}
+	}
+
+	g := newGenericContiguousBST(0, cmp.Compare[int])
+	for val := 0; val < n; val++ {
+		g.insert(val)
+	}
+	if st := g.stats(); st.Stored != n || float64(st.Rebuilt) > n*math.Log2(n)*math.Log2(n)/2 {
+		t.Fatalf("generic: %s", st)
+	}
+}
+
+func newBalancedTrees() map[string]balancedTree {
+	return map[string]balancedTree{
+		"avl":      &avlTree{},
+		"avl-slab": newAVLSlab(0),
+		"rb":       &rbTree{},
+		"rb-slab":  newRBSlab(0),
+	}
+}
+
+// go test -bench=Balanced -benchmem
+// Sorted input is the unbalanced trees' worst case and changes nothing here.
+
+func BenchmarkBalanced(b *testing.B) {
+	values, searches := setup(treeSize)
+	sorted := slices.Sorted(slices.Values(values))
+
+	for _, order := range []string{"random", "sorted"} {
+		input := values
+		if order == "sorted" {
+			input = sorted
+		}
+		for _, name := range []string{"avl", "avl-slab", "rb", "rb-slab"} {
+			b.Run(order+"/"+name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					t := newBalancedTrees()[name]
+					for _, val := range input {
+						t.insert(val)
+					}
+
+					s := false
+					for _, search := range searches {
+						s = t.search(search)
+					}
+					_ = s
+				}
+			})
+		}
+	}
+}
+
+func TestBalancedTrees(t *testing.T) {
+	values, searches := setup(50_000)
+	unique := sortedUnique(values)
+	// An AVL tree is at most 1.44 log2(n) high, a red-black tree 2 log2(n+1).
+	limit := map[string]float64{
+		"avl": 1.44 * math.Log2(float64(len(unique)+2)),
+		"rb":  2 * math.Log2(float64(len(unique)+1)),
+	}
+
+	for _, input := range [][]int{values, unique} {
+		var root *node
+		for _, val := range values {
+			root = root.insert(val)
+		}
+		for name, tree := range newBalancedTrees() {
+			for _, val := range input {
+				tree.insert(val)
+			}
+			for _, val := range append(searches, values...) {
+				if got, want := tree.search(val), root.search(val); got != want {
+					t.Fatalf("%s: search(%d) = %t, want %t", name, val, got, want)
+				}
+			}
+			if h := tree.height(); float64(h) > limit[strings.TrimSuffix(name, "-slab")] {
+				t.Errorf("%s: height %d for %d values", name, h, len(unique))
+			}
+
+			switch tree := tree.(type) {
+			case *avlTree:
+				checkAVL(t, tree.root)
+			case *rbTree:
+				if tree.root.red {
+					t.Error("rb: red root")
+				}
+				checkRB(t, tree.root)
+			case *avlSlab:
+				checkAVLSlab(t, tree, tree.root)
+			case *rbSlab:
+				if tree.root >= 0 && (tree.nodes[tree.root].red || tree.nodes[tree.root].parent != -1) {
+					t.Error("rb-slab: red root or root with a parent")
+				}
+				checkRBSlab(t, tree, tree.root)
+			}
+		}
+	}
+}
+
+// checkAVL verifies the stored heights and the balance of every subtree and
+// returns the height of n.
+func checkAVL(t *testing.T, n *avlNode) int32 {
+	if n == nil {
+		return 0
+	}
+	l, r := checkAVL(t, n.left), checkAVL(t, n.right)
+	if l-r > 1 || r-l > 1 || n.height != 1+max(l, r) {
+		t.Fatalf("avl: node %d has height %d, subtrees %d and %d", n.value, n.height, l, r)
+	}
+	return n.height
+}
+
+// checkRB verifies that no red node has a red child and that every path has
+// the same number of black nodes, which it returns.
+func checkRB(t *testing.T, n *rbNode) int {
+	if n == nil {
+		return 1
+	}
+	if n.red && (n.left != nil && n.left.red || n.right != nil && n.right.red) {
+		t.Fatalf("rb: red node %d has a red child", n.value)
+	}
+	for _, c := range []*rbNode{n.left, n.right} {
+		if c != nil && c.parent != n {
+			t.Fatalf("rb: child %d of %d has a wrong parent", c.value, n.value)
+		}
+	}
+	l, r := checkRB(t, n.left), checkRB(t, n.right)
+	if l != r {
+		t.Fatalf("rb: node %d has black heights %d and %d", n.value, l, r)
+	}
+	if n.red {
+		return l
+	}
+	return l + 1
+}
+
+// checkAVLSlab is checkAVL over slab indices, -1 being nil.
+func checkAVLSlab(t *testing.T, s *avlSlab, i int32) int32 {
+	if i < 0 {
+		return 0
+	}
+	n := s.nodes[i]
+	l, r := checkAVLSlab(t, s, n.left), checkAVLSlab(t, s, n.right)
+	if l-r > 1 || r-l > 1 || n.height != 1+max(l, r) {
+		t.Fatalf("avl-slab: node %d has height %d, subtrees %d and %d", n.value, n.height, l, r)
+	}
+	return n.height
+}
+
+// checkRBSlab is checkRB over slab indices, -1 being nil.
+func checkRBSlab(t *testing.T, s *rbSlab, i int32) int {
+	if i < 0 {
+		return 1
+	}
+	n := s.nodes[i]
+	if n.red && (s.isRed(n.left) || s.isRed(n.right)) {
+		t.Fatalf("rb-slab: red node %d has a red child", n.value)
+	}
+	for _, c := range []int32{n.left, n.right} {
+		if c >= 0 && s.nodes[c].parent != i {
+			t.Fatalf("rb-slab: child %d of %d has a wrong parent", s.nodes[c].value, n.value)
+		}
+	}
+	l, r := checkRBSlab(t, s, n.left), checkRBSlab(t, s, n.right)
+	if l != r {
+		t.Fatalf("rb-slab: node %d has black heights %d and %d", n.value, l, r)
+	}
+	if n.red {
+		return l
+	}
+	return l + 1
+}
+
+// go test -bench=GenericKeys -benchmem
+// gc-mark-ms/op is the GC's mark CPU per iteration, scan-MB the scannable
+// heap after the last one with the tree still reachable.
+
+func BenchmarkGenericKeys(b *testing.B) {
+	values, searches := setup(treeSize)
+
+	for _, version := range []string{"ptr", "array"} {
+		b.Run(version+"/int", func(b *testing.B) {
+			benchKeys(b, version, values, searches, cmp.Compare[int])
+		})
+		b.Run(version+"/string", func(b *testing.B) {
+			benchKeys(b, version, keysOf(values, stringKey), keysOf(searches, stringKey), strings.Compare)
+		})
+		b.Run(version+"/ptr", func(b *testing.B) {
+			benchKeys(b, version, keysOf(values, newPtrKey), keysOf(searches, newPtrKey), comparePtrKey)
+		})
+	}
+}
+
+func benchKeys[K any](b *testing.B, version string, keys, searches []K, cmp func(a, b K) int) {
+	b.ReportAllocs()
+	before := readGCWork()
+	b.ResetTimer()
+
+	var res keyResult
+	for i := 0; i < b.N; i++ {
+		res = runKeys(version, treeSize, keys, searches, cmp)
+	}
+	b.StopTimer()
+	gc := readGCWork().sub(before)
+	runtime.GC()
+	runtime.KeepAlive(res.live)
+	b.ReportMetric(1000*gc.mark()/float64(b.N), "gc-mark-ms/op")
+	b.ReportMetric(float64(readGCWork().scanHeap)/(1<<20), "scan-MB")
+}
+
+func TestGenericTreesMatchPointerBST(t *testing.T) {
+	values, searches := setup(100_000)
+	var root *node
+	for _, val := range values {
+		root = root.insert(val)
+	}
+	want := 0
+	for _, search := range searches {
+		if root.search(search) {
+			want++
+		}
+	}
+
+	for _, version := range []string{"ptr", "array"} {
+		for key, res := range map[keyKind]keyResult{
+			intKeys:    runKeys(version, len(values), values, searches, cmp.Compare[int]),
+			stringKeys: runKeys(version, len(values), keysOf(values, stringKey), keysOf(searches, stringKey), strings.Compare),
+			ptrKeys:    runKeys(version, len(values), keysOf(values, newPtrKey), keysOf(searches, newPtrKey), comparePtrKey),
+		} {
+			if res.found != want {
+				t.Errorf("%s/%s: found %d, want %d", version, key, res.found, want)
+			}
+			if version == "ptr" && res.height() != root.height() {
+				t.Errorf("%s/%s: height %d, want %d", version, key, res.height(), root.height())
+			}
+			if version == "array" && res.stats.Stored != len(sortedUnique(values)) {
+				t.Errorf("%s/%s: stored %d of %d values", version, key, res.stats.Stored, len(sortedUnique(values)))
+			}
+		}
+	}
+}
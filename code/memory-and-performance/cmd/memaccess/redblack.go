@@ -0,0 +1,307 @@
+package main
+
+// rbNode is a red-black tree node. Missing children are nil and count as
+// black. The parent link lets insert fix the tree up without a path stack.
+type rbNode struct {
+	value               int
+	left, right, parent *rbNode
+	red                 bool
+}
+
+type rbTree struct {
+	root *rbNode
+}
+
+func (t *rbTree) insert(val int) {
+	var parent *rbNode
+	n := t.root
+	for n != nil {
+		if val == n.value {
+			return // Duplicate value
+		}
+		parent = n
+		if val < n.value {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+
+	z := &rbNode{value: val, parent: parent, red: true}
+	switch {
+	case parent == nil:
+		t.root = z
+	case val < parent.value:
+		parent.left = z
+	default:
+		parent.right = z
+	}
+	t.fixup(z)
+}
+
+// fixup restores the red-black properties after inserting the red node z
+// (Cormen et al., RB-INSERT-FIXUP).
+func (t *rbTree) fixup(z *rbNode) {
+	for z.parent != nil && z.parent.red {
+		p := z.parent
+		g := p.parent // a red node is never the root
+		if p == g.left {
+			if u := g.right; u != nil && u.red {
+				p.red, u.red, g.red = false, false, true
+				z = g
+				continue
+			}
+			if z == p.right {
+				z = p
+				t.rotateLeft(z)
+				p = z.parent
+			}
+			p.red, g.red = false, true
+			t.rotateRight(g)
+		} else {
+			if u := g.left; u != nil && u.red {
+				p.red, u.red, g.red = false, false, true
+				z = g
+				continue
+			}
+			if z == p.left {
+				z = p
+				t.rotateRight(z)
+				p = z.parent
+			}
+			p.red, g.red = false, true
+			t.rotateLeft(g)
+		}
+	}
+	t.root.red = false
+}
+
+// replace points x's parent, or the root, at y.
+func (t *rbTree) replace(x, y *rbNode) {
+	y.parent = x.parent
+	switch {
+	case x.parent == nil:
+		t.root = y
+	case x == x.parent.left:
+		x.parent.left = y
+	default:
+		x.parent.right = y
+	}
+}
+
+func (t *rbTree) rotateLeft(x *rbNode) {
+	y := x.right
+	x.right = y.left
+	if y.left != nil {
+		y.left.parent = x
+	}
+	t.replace(x, y)
+	y.left, x.parent = x, y
+}
+
+func (t *rbTree) rotateRight(x *rbNode) {
+	y := x.left
+	x.left = y.right
+	if y.right != nil {
+		y.right.parent = x
+	}
+	t.replace(x, y)
+	y.right, x.parent = x, y
+}
+
+func (t *rbTree) search(val int) bool {
+	n := t.root
+	for n != nil {
+		if val == n.value {
+			return true
+		}
+		if val < n.value {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return false
+}
+
+// height walks the tree with an explicit stack, red-black trees do not store
+// it.
+func (t *rbTree) height() int {
+	type entry struct {
+		n     *rbNode
+		depth int
+	}
+	height := 0
+	stack := []entry{{t.root, 1}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.n == nil {
+			continue
+		}
+		height = max(height, e.depth)
+		stack = append(stack, entry{e.n.left, e.depth + 1}, entry{e.n.right, e.depth + 1})
+	}
+	return height
+}
+
+// rbSlabNode is rbNode linked by int32 indices into rbSlab.nodes, -1 meaning
+// nil.
+type rbSlabNode struct {
+	value               int
+	left, right, parent int32
+	red                 bool
+}
+
+// rbSlab is rbTree with every node in one pointer-free slab.
+type rbSlab struct {
+	nodes []rbSlabNode
+	root  int32
+}
+
+func newRBSlab(capacity int) *rbSlab {
+	return &rbSlab{
+		nodes: make([]rbSlabNode, 0, capacity),
+		root:  -1,
+	}
+}
+
+func (t *rbSlab) isRed(i int32) bool {
+	return i >= 0 && t.nodes[i].red
+}
+
+func (t *rbSlab) insert(val int) {
+	parent := int32(-1)
+	i := t.root
+	for i >= 0 {
+		n := &t.nodes[i]
+		if val == n.value {
+			return // Duplicate value
+		}
+		parent = i
+		if val < n.value {
+			i = n.left
+		} else {
+			i = n.right
+		}
+	}
+
+	t.nodes = append(t.nodes, rbSlabNode{value: val, left: -1, right: -1, parent: parent, red: true})
+	z := int32(len(t.nodes) - 1)
+	switch {
+	case parent < 0:
+		t.root = z
+	case val < t.nodes[parent].value:
+		t.nodes[parent].left = z
+	default:
+		t.nodes[parent].right = z
+	}
+	t.fixup(z)
+}
+
+func (t *rbSlab) fixup(z int32) {
+	nodes := t.nodes
+	for t.isRed(nodes[z].parent) {
+		p := nodes[z].parent
+		g := nodes[p].parent
+		if p == nodes[g].left {
+			if u := nodes[g].right; t.isRed(u) {
+				nodes[p].red, nodes[u].red, nodes[g].red = false, false, true
+				z = g
+				continue
+			}
+			if z == nodes[p].right {
+				z = p
+				t.rotateLeft(z)
+				p = nodes[z].parent
+			}
+			nodes[p].red, nodes[g].red = false, true
+			t.rotateRight(g)
+		} else {
+			if u := nodes[g].left; t.isRed(u) {
+				nodes[p].red, nodes[u].red, nodes[g].red = false, false, true
+				z = g
+				continue
+			}
+			if z == nodes[p].left {
+				z = p
+				t.rotateRight(z)
+				p = nodes[z].parent
+			}
+			nodes[p].red, nodes[g].red = false, true
+			t.rotateLeft(g)
+		}
+	}
+	nodes[t.root].red = false
+}
+
+func (t *rbSlab) replace(x, y int32) {
+	nodes := t.nodes
+	p := nodes[x].parent
+	nodes[y].parent = p
+	switch {
+	case p < 0:
+		t.root = y
+	case x == nodes[p].left:
+		nodes[p].left = y
+	default:
+		nodes[p].right = y
+	}
+}
+
+func (t *rbSlab) rotateLeft(x int32) {
+	nodes := t.nodes
+	y := nodes[x].right
+	nodes[x].right = nodes[y].left
+	if l := nodes[y].left; l >= 0 {
+		nodes[l].parent = x
+	}
+	t.replace(x, y)
+	nodes[y].left, nodes[x].parent = x, y
+}
+
+func (t *rbSlab) rotateRight(x int32) {
+	nodes := t.nodes
+	y := nodes[x].left
+	nodes[x].left = nodes[y].right
+	if r := nodes[y].right; r >= 0 {
+		nodes[r].parent = x
+	}
+	t.replace(x, y)
+	nodes[y].right, nodes[x].parent = x, y
+}
+
+func (t *rbSlab) search(val int) bool {
+	i := t.root
+	for i >= 0 {
+		n := &t.nodes[i]
+		if val == n.value {
+			return true
+		}
+		if val < n.value {
+			i = n.left
+		} else {
+			i = n.right
+		}
+	}
+	return false
+}
+
+func (t *rbSlab) height() int {
+	type entry struct {
+		i     int32
+		depth int
+	}
+	height := 0
+	stack := []entry{{t.root, 1}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.i < 0 {
+			continue
+		}
+		height = max(height, e.depth)
+		stack = append(stack, entry{t.nodes[e.i].left, e.depth + 1}, entry{t.nodes[e.i].right, e.depth + 1})
+	}
+	return height
+}
@@ -0,0 +1,94 @@
+package main
+
+// slabNode is node with the pointers replaced by int32 indices into
+// slabBST.nodes, -1 meaning nil. The tree has the same shape and the same
+// recursive insert and search as node, and nodes are still placed in
+// insertion order rather than by position in the tree, so against node it
+// isolates the cost of GC-visible pointers and per-node allocation, and
+// against contiguousBST the cost of scattered placement.
+type slabNode struct {
+	value       int   // 8 bytes at offset 0
+	left, right int32 // 4 bytes each at offsets 8 and 12
+}
+
+// slabBST keeps every node in one slab, a pointer-free slice the GC does
+// not scan.
+type slabBST struct {
+	nodes []slabNode
+	root  int32
+}
+
+// newSlabBST returns an empty tree with room for capacity nodes before the
+// slab has to grow.
+func newSlabBST(capacity int) *slabBST {
+	return &slabBST{
+		nodes: make([]slabNode, 0, capacity),
+		root:  -1,
+	}
+}
+
+func (t *slabBST) insert(val int) {
+	t.root = t.insertAt(t.root, val)
+}
+
+// insertAt mirrors node.insert and returns the index of the subtree root.
+func (t *slabBST) insertAt(i int32, val int) int32 {
+	if i < 0 {
+		// Appends to the slab: the new node lands next to the previously
+		// inserted one, not next to its parent
+		t.nodes = append(t.nodes, slabNode{value: val, left: -1, right: -1})
+		return int32(len(t.nodes) - 1)
+	}
+
+	// The recursive call may grow the slab, so the result is stored through
+	// a fresh t.nodes afterwards
+	if val < t.nodes[i].value {
+		left := t.insertAt(t.nodes[i].left, val)
+		t.nodes[i].left = left
+	} else if val > t.nodes[i].value {
+		right := t.insertAt(t.nodes[i].right, val)
+		t.nodes[i].right = right
+	}
+
+	return i
+}
+
+func (t *slabBST) search(val int) bool {
+	return t.searchAt(t.root, val)
+}
+
+// searchAt mirrors node.search, following indices instead of pointers
+func (t *slabBST) searchAt(i int32, val int) bool {
+	if i < 0 {
+		return false
+	}
+
+	n := &t.nodes[i]
+	if val == n.value {
+		return true
+	}
+
+	if val < n.value {
+		return t.searchAt(n.left, val)
+	}
+	return t.searchAt(n.right, val)
+}
+
+func (t *slabBST) height() int {
+	type entry struct {
+		i     int32
+		depth int
+	}
+	height := 0
+	stack := []entry{{t.root, 1}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.i < 0 {
+			continue
+		}
+		height = max(height, e.depth)
+		stack = append(stack, entry{t.nodes[e.i].left, e.depth + 1}, entry{t.nodes[e.i].right, e.depth + 1})
+	}
+	return height
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/gctrace"
+	"github.com/Elvis339/go_gc_eval/internal/plot"
+	"github.com/Elvis339/go_gc_eval/internal/sampler"
+)
+
+const (
+	heapObjects = "/memory/classes/heap/objects:bytes"
+	heapGoal    = "/gc/heap/goal:bytes"
+	gcCycles    = "/gc/cycles/total:gc-cycles"
+	gcCPU       = "/cpu/classes/gc/total:cpu-seconds"
+	totalCPU    = "/cpu/classes/total:cpu-seconds"
+	goroutines  = "/sched/goroutines:goroutines"
+)
+
+// pauseHistograms in order of preference, see sampler.Histograms.
+var pauseHistograms = []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}
+
+type run struct {
+	Label   string
+	Samples []sampler.Sample
+	GC      []gctrace.Record // optional
+}
+
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// go run ./cmd/metricsreport -o traces/report.html traces/graph_compact_std_metrics.jsonl
+//
+//	go run ./cmd/metricsreport -gctrace traces/graph.gctrace -gctrace traces/graphx.gctrace \
+//	  traces/graph_compact_std_metrics.jsonl traces/graphx_compact_greenteagc_metrics.jsonl
+func main() {
+	out := flag.String("o", "traces/report.html", "Output HTML file")
+	title := flag.String("title", "Runtime metrics report", "Report title")
+	var traces, labels stringList
+	flag.Var(&traces, "gctrace", "gctrace file of the matching run, repeat once per run in the same order")
+	flag.Var(&labels, "label", "Label of the matching run, repeat once per run (default: file name)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <metrics.jsonl|csv>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || len(traces) > flag.NArg() || len(labels) > flag.NArg() {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var runs []run
+	for i, path := range flag.Args() {
+		samples, err := sampler.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r := run{Label: label(path), Samples: samples}
+		if i < len(labels) {
+			r.Label = labels[i]
+		}
+		if i < len(traces) {
+			if r.GC, err = gctrace.ParseFile(traces[i]); err != nil {
+				log.Fatal(err)
+			}
+		}
+		runs = append(runs, r)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := render(f, *title, runs); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Generated:", *out)
+}
+
+func label(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.TrimSuffix(base, "_metrics")
+}
+
+type summaryRow struct {
+	Label      string
+	Duration   string
+	Samples    int
+	GCCycles   int
+	GCCPU      string
+	PeakHeap   string
+	WorstMark  string
+	MaxGCPct   string
+	Goroutines string
+}
+
+type page struct {
+	Title     string
+	Generated string
+	Summary   []summaryRow
+	Charts    []template.HTML
+	Notes     []string
+}
+
+func render(w io.Writer, title string, runs []run) error {
+	p := page{Title: title, Generated: time.Now().Format(time.RFC1123)}
+
+	for _, r := range runs {
+		p.Summary = append(p.Summary, summarize(r))
+	}
+
+	var svgs []string
+	for _, c := range []svgChart{heapChart(runs), gcCPUChart(runs), goroutineChart(runs)} {
+		var b strings.Builder
+		if err := c.SVG(&b); err != nil {
+			return err
+		}
+		svgs = append(svgs, b.String())
+	}
+	if hist, ok := pauseChart(runs); ok {
+		var b strings.Builder
+		if err := hist.SVG(&b); err != nil {
+			return err
+		}
+		svgs = append(svgs, b.String())
+	} else {
+		p.Notes = append(p.Notes, "No pause histogram: record with -metrics-format jsonl to keep histograms.")
+	}
+	for _, s := range svgs {
+		// The SVG is generated by internal/plot, which escapes every label.
+		p.Charts = append(p.Charts, template.HTML(s))
+	}
+
+	return reportTemplate.Execute(w, p)
+}
+
+type svgChart interface {
+	SVG(w io.Writer) error
+}
+
+func summarize(r run) summaryRow {
+	row := summaryRow{Label: r.Label, Samples: len(r.Samples), WorstMark: "-", MaxGCPct: "-"}
+	if len(r.Samples) == 0 {
+		return row
+	}
+	first, last := r.Samples[0], r.Samples[len(r.Samples)-1]
+	row.Duration = (last.Elapsed - first.Elapsed).Round(time.Millisecond).String()
+	row.GCCycles = int(last.Values[gcCycles] - first.Values[gcCycles])
+	row.GCCPU = fmt.Sprintf("%.1fms", (last.Values[gcCPU]-first.Values[gcCPU])*1e3)
+
+	peak, maxG := 0.0, 0.0
+	for _, s := range r.Samples {
+		peak = max(peak, s.Values[heapObjects])
+		maxG = max(maxG, s.Values[goroutines])
+	}
+	row.PeakHeap = fmt.Sprintf("%.1fMB", peak/(1<<20))
+	row.Goroutines = fmt.Sprintf("%.0f", maxG)
+
+	if len(r.GC) > 0 {
+		s := gctrace.Summarize(r.GC)
+		row.WorstMark = fmt.Sprintf("%s (GC %d)", s.WorstMark.Round(10*time.Microsecond), s.WorstMarkCycle)
+		row.MaxGCPct = fmt.Sprintf("%d%% (GC %d)", s.MaxGCPercent, s.MaxGCPercentCycle)
+	}
+	return row
+}
+
+func seconds(s sampler.Sample) float64 {
+	return s.Elapsed.Seconds()
+}
+
+// heapChart plots heap in use (solid) and the heap goal (dashed) of every run,
+// with a marker where each GC cycle completed.
+func heapChart(runs []run) *plot.LineChart {
+	c := &plot.LineChart{Title: "Heap over time", XLabel: "seconds", YLabel: "MB"}
+	for i, r := range runs {
+		color := plot.Palette[i%len(plot.Palette)]
+		objects := plot.Series{Name: r.Label, Color: color}
+		goal := plot.Series{Name: r.Label + " goal", Color: color, Dashed: true}
+		for _, s := range r.Samples {
+			objects.X = append(objects.X, seconds(s))
+			objects.Y = append(objects.Y, s.Values[heapObjects]/(1<<20))
+			goal.X = append(goal.X, seconds(s))
+			goal.Y = append(goal.Y, s.Values[heapGoal]/(1<<20))
+		}
+		c.Series = append(c.Series, objects, goal)
+		c.Markers = append(c.Markers, gcMarkers(i, r)...)
+	}
+	return c
+}
+
+// gcMarkers places a marker at the first sample that observed each new GC
+// cycle. With a gctrace file the cycle number lines up with the trace record,
+// which becomes the tooltip.
+func gcMarkers(i int, r run) []plot.Marker {
+	byCycle := make(map[int]gctrace.Record, len(r.GC))
+	for _, rec := range r.GC {
+		byCycle[rec.Cycle] = rec
+	}
+
+	var markers []plot.Marker
+	for j := 1; j < len(r.Samples); j++ {
+		prev, cur := int(r.Samples[j-1].Values[gcCycles]), int(r.Samples[j].Values[gcCycles])
+		for cycle := prev + 1; cycle <= cur; cycle++ {
+			label := fmt.Sprintf("%s: GC %d", r.Label, cycle)
+			if rec, ok := byCycle[cycle]; ok {
+				label = fmt.Sprintf("%s: GC %d, %d%%, mark %s, %g->%g->%g MB", r.Label, cycle, rec.GCPercent,
+					rec.Clock.Mark, rec.HeapBefore, rec.HeapAfter, rec.HeapLive)
+			}
+			markers = append(markers, plot.Marker{X: seconds(r.Samples[j]), Series: i, Label: label})
+		}
+	}
+	return markers
+}
+
+// gcCPUChart plots the share of CPU time used by the GC in every interval.
+func gcCPUChart(runs []run) *plot.LineChart {
+	c := &plot.LineChart{Title: "GC CPU per sample interval", XLabel: "seconds", YLabel: "% of CPU"}
+	for i, r := range runs {
+		s := plot.Series{Name: r.Label, Color: plot.Palette[i%len(plot.Palette)]}
+		for j := 1; j < len(r.Samples); j++ {
+			prev, cur := r.Samples[j-1], r.Samples[j]
+			total := cur.Values[totalCPU] - prev.Values[totalCPU]
+			pct := 0.0
+			if total > 0 {
+				pct = (cur.Values[gcCPU] - prev.Values[gcCPU]) / total * 100
+			}
+			s.X = append(s.X, seconds(cur))
+			s.Y = append(s.Y, pct)
+		}
+		c.Series = append(c.Series, s)
+	}
+	return c
+}
+
+func goroutineChart(runs []run) *plot.LineChart {
+	c := &plot.LineChart{Title: "Goroutines", XLabel: "seconds", YLabel: "goroutines", Height: 240}
+	for i, r := range runs {
+		s := plot.Series{Name: r.Label, Color: plot.Palette[i%len(plot.Palette)]}
+		for _, sample := range r.Samples {
+			s.X = append(s.X, seconds(sample))
+			s.Y = append(s.Y, sample.Values[goroutines])
+		}
+		c.Series = append(c.Series, s)
+	}
+	return c
+}
+
+// pauseChart draws the GC pause distribution of the measured region: the
+// difference between the last and first cumulative histogram of each run.
+func pauseChart(runs []run) (*plot.BarChart, bool) {
+	type bucket struct{ lo, hi float64 }
+	counts := make([]map[bucket]uint64, len(runs))
+	all := make(map[bucket]bool)
+	found := false
+
+	for i, r := range runs {
+		counts[i] = make(map[bucket]uint64)
+		if len(r.Samples) == 0 {
+			continue
+		}
+		first, last := pauses(r.Samples[0]), pauses(r.Samples[len(r.Samples)-1])
+		if last == nil {
+			continue
+		}
+		found = true
+		for _, b := range last {
+			counts[i][bucket{b.Lo, b.Hi}] += b.Count
+			all[bucket{b.Lo, b.Hi}] = true
+		}
+		for _, b := range first {
+			counts[i][bucket{b.Lo, b.Hi}] -= b.Count
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	buckets := make([]bucket, 0, len(all))
+	for b := range all {
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].hi < buckets[j].hi })
+
+	c := &plot.BarChart{Title: "GC pause distribution (STW)", XLabel: "pause up to", YLabel: "pauses"}
+	for _, b := range buckets {
+		c.Categories = append(c.Categories, pauseLabel(b.hi))
+	}
+	for i, r := range runs {
+		s := plot.BarSeries{Name: r.Label}
+		for _, b := range buckets {
+			s.Values = append(s.Values, float64(counts[i][b]))
+		}
+		c.Series = append(c.Series, s)
+	}
+	return c, true
+}
+
+// pauseLabel formats a bucket's upper bound in seconds. The top bucket's
+// bound is +Inf clamped to math.MaxFloat64, past what a Duration holds.
+func pauseLabel(hi float64) string {
+	if hi >= math.MaxInt64/float64(time.Second) {
+		return "+Inf"
+	}
+	return time.Duration(hi * float64(time.Second)).String()
+}
+
+func pauses(s sampler.Sample) sampler.Histogram {
+	for _, name := range pauseHistograms {
+		if h, ok := s.Histograms[name]; ok {
+			return h
+		}
+	}
+	return nil
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 860px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+figure { margin: 0 0 2em 0; }
+.note { color: #777; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="note">Generated {{.Generated}}. Hover markers and bars for details.</p>
+<table>
+<tr><th>Run</th><th>Duration</th><th>Samples</th><th>GC cycles</th><th>GC CPU</th><th>Peak heap</th><th>Max goroutines</th><th>Worst mark</th><th>Highest GC %</th></tr>
+{{range .Summary}}<tr><td>{{.Label}}</td><td>{{.Duration}}</td><td>{{.Samples}}</td><td>{{.GCCycles}}</td><td>{{.GCCPU}}</td><td>{{.PeakHeap}}</td><td>{{.Goroutines}}</td><td>{{.WorstMark}}</td><td>{{.MaxGCPct}}</td></tr>
+{{end}}</table>
+{{range .Charts}}<figure>{{.}}</figure>
+{{end}}{{range .Notes}}<p class="note">{{.}}</p>
+{{end}}</body>
+</html>
+`))
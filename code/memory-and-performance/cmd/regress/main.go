@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Elvis339/go_gc_eval/internal/harness"
+	"github.com/Elvis339/go_gc_eval/internal/results"
+	"github.com/Elvis339/go_gc_eval/internal/stats"
+)
+
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// metric is one compared number of a run. Larger values are worse.
+type metric struct {
+	name      string
+	unit      string
+	scale     float64 // divide raw values by scale before printing
+	threshold *float64
+	value     func(r *results.Record) float64
+}
+
+// Exit statuses, so a CI gate can tell a regression from a comparison that
+// never happened.
+const (
+	exitRegression = 1
+	exitUsage      = 2
+	exitNoRuns     = 3 // nothing was compared: no overlap or every key skipped
+)
+
+// key identifies runs that measure the same thing. Harness flags are not part
+// of it, so repetitions written to different -out directories, as labrun and
+// gcsweep do, are grouped together.
+type key struct {
+	experiment, variant, args, env string
+}
+
+func keyOf(r *results.Record) key {
+	env := make([]string, 0, len(r.Env))
+	for k, v := range r.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return key{r.Experiment, r.Variant, strings.Join(harness.ExperimentArgs(r.Args), " "), strings.Join(env, " ")}
+}
+
+// go run ./cmd/regress -base go=go1.26.0 -head go=go1.27.0 exp=graph
+// go run ./cmd/regress -base until=2026-10-01 -head since=2026-10-01 -time 3
+func main() {
+	var base, head stringList
+	flag.Var(&base, "base", "Filter term selecting the baseline runs, repeatable, e.g. go=go1.26.0 or rev=85c1c22")
+	flag.Var(&head, "head", "Filter term selecting the runs checked for regressions, repeatable")
+	store := flag.String("store", results.DefaultPath, "Results store to read")
+	alpha := flag.Float64("alpha", 0.05, "Significance level of the Mann-Whitney U test")
+	minRuns := flag.Int("min-runs", 5, "Skip comparisons with fewer runs on either side, must be enough to reach p < alpha")
+	timeThreshold := flag.Float64("time", 5, "Wall time increase in percent that counts as a regression")
+	memThreshold := flag.Float64("mem", 10, "Peak RSS increase in percent that counts as a regression")
+	gcThreshold := flag.Float64("gc", 0, "GC CPU increase in percent that counts as a regression (0 only reports it)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -base key=value... -head key=value... [flags] [key=value...]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Trailing key=value terms apply to both sides, see cmd/results for the keys.\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Exits with status 1 when a regression is found, 2 on usage errors and 3 when nothing could be compared.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(base) == 0 || len(head) == 0 {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+	// With too few runs even two samples that do not overlap stay above
+	// alpha and the gate could never fail.
+	if p := stats.MannWhitneyUMinP(*minRuns, *minRuns); p >= *alpha {
+		fmt.Fprintf(os.Stderr, "-min-runs %d cannot detect anything at -alpha %g: the smallest possible p is %.3f\n", *minRuns, *alpha, p)
+		os.Exit(exitUsage)
+	}
+
+	common := flag.Args()
+	baseFilter, err := results.ParseFilter(append(append([]string(nil), common...), base...)...)
+	if err != nil {
+		fatal(exitUsage, err)
+	}
+	headFilter, err := results.ParseFilter(append(append([]string(nil), common...), head...)...)
+	if err != nil {
+		fatal(exitUsage, err)
+	}
+
+	all, err := results.Read(*store)
+	if err != nil {
+		fatal(exitNoRuns, err)
+	}
+	baseRuns, headRuns := group(baseFilter.Apply(all)), group(headFilter.Apply(all))
+
+	metrics := []metric{
+		{"wall time", "ms", 1e6, timeThreshold, func(r *results.Record) float64 { return float64(r.Wall) }},
+		{"peak rss", "MB", 1 << 20, memThreshold, func(r *results.Record) float64 { return float64(r.MaxRSS) }},
+		{"gc cpu", "ms", 1e6, gcThreshold, func(r *results.Record) float64 { return float64(r.GCCPU) }},
+	}
+
+	var keys []key
+	for k := range headRuns {
+		if _, ok := baseRuns[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.experiment != b.experiment {
+			return a.experiment < b.experiment
+		}
+		if a.variant != b.variant {
+			return a.variant < b.variant
+		}
+		if a.args != b.args {
+			return a.args < b.args
+		}
+		return a.env < b.env
+	})
+	if len(keys) == 0 {
+		fatal(exitNoRuns, fmt.Errorf("no experiment has runs on both sides (base: %d runs, head: %d runs)", count(baseRuns), count(headRuns)))
+	}
+
+	fmt.Printf("base: %s\nhead: %s\n\n", baseFilter, headFilter)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "experiment\targs\tenv\tmetric\tbase\thead\tdelta\tp\tverdict")
+	regressions, skipped := 0, 0
+	for _, k := range keys {
+		b, h := baseRuns[k], headRuns[k]
+		if len(b) < *minRuns || len(h) < *minRuns {
+			skipped++
+			continue
+		}
+		for _, m := range metrics {
+			bs, hs := sample(b, m), sample(h, m)
+			bm, hm := bs.Median(), hs.Median()
+			delta := 0.0
+			if bm != 0 {
+				delta = (hm - bm) / bm * 100
+			}
+			p := stats.MannWhitneyU(bs, hs)
+
+			verdict := "~"
+			switch {
+			case p >= *alpha:
+			case delta > 0 && *m.threshold > 0 && delta >= *m.threshold:
+				verdict = "REGRESSION"
+				regressions++
+			case delta > 0:
+				verdict = "slower"
+				if m.unit == "MB" {
+					verdict = "larger"
+				}
+			case delta < 0:
+				verdict = "better"
+			}
+
+			name := k.experiment
+			if k.variant != "" {
+				name += " " + k.variant
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.4g%s (n=%d)\t%.4g%s (n=%d)\t%+.1f%%\t%.3f\t%s\n",
+				name, k.args, dash(k.env), m.name, bm/m.scale, m.unit, len(bs), hm/m.scale, m.unit, len(hs), delta, p, verdict)
+		}
+	}
+	tw.Flush()
+
+	if skipped > 0 {
+		fmt.Printf("\n%d experiments skipped with fewer than %d runs on a side\n", skipped, *minRuns)
+	}
+	if skipped == len(keys) {
+		fmt.Println("Nothing compared")
+		os.Exit(exitNoRuns)
+	}
+	if regressions > 0 {
+		fmt.Printf("\nRegressions found: %d\n", regressions)
+		os.Exit(exitRegression)
+	}
+}
+
+// fatal logs err like log.Fatal but exits with code.
+func fatal(code int, err error) {
+	log.Print(err)
+	os.Exit(code)
+}
+
+func group(records []results.Record) map[key][]results.Record {
+	out := make(map[key][]results.Record)
+	for i := range records {
+		k := keyOf(&records[i])
+		out[k] = append(out[k], records[i])
+	}
+	return out
+}
+
+func count(groups map[key][]results.Record) int {
+	n := 0
+	for _, rs := range groups {
+		n += len(rs)
+	}
+	return n
+}
+
+func sample(records []results.Record, m metric) stats.Sample {
+	s := make(stats.Sample, 0, len(records))
+	for i := range records {
+		s = append(s, m.value(&records[i]))
+	}
+	return s
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
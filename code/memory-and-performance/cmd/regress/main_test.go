@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/results"
+)
+
+// TestGroupMatrixRepetitions feeds records the way labrun and gcsweep leave
+// them: matrix.runRep passes the harness flags first, with a different -out
+// directory for every repetition, followed by the cell's own arguments.
+func TestGroupMatrixRepetitions(t *testing.T) {
+	cell := filepath.Join("traces", "matrix", "layouts", "003-graph-v-compact")
+	var records []results.Record
+	for _, variant := range []string{"compact", "ptr-chasing"} {
+		for rep := 1; rep <= 5; rep++ {
+			args := []string{"-out", filepath.Join(cell, fmt.Sprintf("rep%d", rep)), "-p", "-metrics", "50ms", "-trace", "-v", variant}
+			records = append(records, results.Record{
+				Time:       time.Now(),
+				Experiment: "graph",
+				Variant:    variant,
+				Args:       args,
+				Env:        map[string]string{"GOGC": "100"},
+			})
+		}
+	}
+
+	groups := group(records)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want one per variant: %v", len(groups), groups)
+	}
+	for _, variant := range []string{"compact", "ptr-chasing"} {
+		k := key{experiment: "graph", variant: variant, args: "-v " + variant, env: "GOGC=100"}
+		if n := len(groups[k]); n != 5 {
+			t.Errorf("%s: %d runs grouped under %+v, want 5", variant, n, k)
+		}
+	}
+}
+
+func TestKeyKeepsExperimentArgs(t *testing.T) {
+	a := results.Record{Experiment: "graph", Args: []string{"-out=traces/a", "-results", "x.jsonl", "-s", "1000"}}
+	b := results.Record{Experiment: "graph", Args: []string{"-out", "traces/b", "-statsviz", "-s", "2000"}}
+	if keyOf(&a) == keyOf(&b) {
+		t.Errorf("different -s grouped together: %+v", keyOf(&a))
+	}
+	if got := keyOf(&a).args; got != "-s 1000" {
+		t.Errorf("args key %q, want %q", got, "-s 1000")
+	}
+}
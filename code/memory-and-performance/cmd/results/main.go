@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/harness"
+	"github.com/Elvis339/go_gc_eval/internal/results"
+	"github.com/Elvis339/go_gc_eval/internal/stats"
+)
+
+// go run ./cmd/results exp=graphx since=24h
+// go run ./cmd/results -group variant=compact
+// go run ./cmd/results -json -last 1
+func main() {
+	store := flag.String("store", results.DefaultPath, "Results store to read")
+	last := flag.Int("last", 0, "Only show the last N matching runs (0 shows all)")
+	group := flag.Bool("group", false, "Aggregate runs with the same experiment, variant, build, arguments and environment")
+	asJSON := flag.Bool("json", false, "Print matching records as JSON lines")
+	machine := flag.Bool("machine", false, "Add Go version, revision, CPU and host columns")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [key=value...]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Filter keys: exp variant build go rev host cpu procs args env.<NAME> since until\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	filter, err := results.ParseFilter(flag.Args()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	all, err := results.Read(*store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	records := filter.Apply(all)
+	if *last > 0 && len(records) > *last {
+		records = records[len(records)-*last:]
+	}
+
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		for i := range records {
+			if err := enc.Encode(&records[i]); err != nil {
+				log.Fatal(err)
+			}
+		}
+	case *group:
+		printGroups(records, *machine)
+	default:
+		printRuns(records, *machine)
+	}
+}
+
+func printRuns(records []results.Record, machine bool) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "time\texperiment\tvariant\tbuild\targs\tenv\twall\tgc cycles\tgc cpu\tgc pause\tallocated\tmax rss"
+	if machine {
+		header += "\tgo\trev\tcpu\thost"
+	}
+	fmt.Fprintln(tw, header)
+	for _, r := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s",
+			r.Time.Local().Format(time.DateTime), r.Experiment, dash(r.Variant), r.Fingerprint.Experiment,
+			strings.Join(r.Args, " "), env(r.Env), d(r.Wall), r.GCCycles, d(r.GCCPU), d(r.GCPause),
+			mb(float64(r.HeapAllocs)), mb(float64(r.MaxRSS)))
+		if machine {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s", r.Fingerprint.GoVersion, short(r.Fingerprint), r.Fingerprint.CPUModel, r.Fingerprint.Hostname)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+type groupKey struct {
+	experiment, variant, build, args, env string
+	goVersion, rev, cpu, host             string
+}
+
+func printGroups(records []results.Record, machine bool) {
+	groups := make(map[groupKey][]results.Record)
+	var order []groupKey
+	for _, r := range records {
+		k := groupKey{
+			experiment: r.Experiment,
+			variant:    r.Variant,
+			build:      r.Fingerprint.Experiment,
+			args:       strings.Join(harness.ExperimentArgs(r.Args), " "),
+			env:        env(r.Env),
+		}
+		if machine {
+			k.goVersion, k.rev, k.cpu, k.host = r.Fingerprint.GoVersion, short(r.Fingerprint), r.Fingerprint.CPUModel, r.Fingerprint.Hostname
+		}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.experiment != b.experiment {
+			return a.experiment < b.experiment
+		}
+		return a.variant < b.variant
+	})
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "experiment\tvariant\tbuild\targs\tenv\truns\tmedian wall\tmin wall\tgc cycles\tgc cpu\tgc pause\tmax rss"
+	if machine {
+		header += "\tgo\trev\tcpu\thost"
+	}
+	fmt.Fprintln(tw, header)
+	for _, k := range order {
+		rs := groups[k]
+		var wall, cycles, cpu, pause, rss stats.Sample
+		for _, r := range rs {
+			wall = append(wall, float64(r.Wall))
+			cycles = append(cycles, float64(r.GCCycles))
+			cpu = append(cpu, float64(r.GCCPU))
+			pause = append(pause, float64(r.GCPause))
+			rss = append(rss, float64(r.MaxRSS))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%.0f\t%s\t%s\t%s",
+			k.experiment, dash(k.variant), k.build, k.args, k.env, len(rs),
+			d(time.Duration(wall.Median())), d(time.Duration(wall.Min())),
+			cycles.Median(), d(time.Duration(cpu.Median())), d(time.Duration(pause.Median())), mb(rss.Median()))
+		if machine {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s", k.goVersion, k.rev, k.cpu, k.host)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func env(m map[string]string) string {
+	if len(m) == 0 {
+		return "-"
+	}
+	kv := make([]string, 0, len(m))
+	for k, v := range m {
+		kv = append(kv, k+"="+v)
+	}
+	sort.Strings(kv)
+	return strings.Join(kv, " ")
+}
+
+func short(fp results.Fingerprint) string {
+	rev := fp.Revision
+	if len(rev) > 7 {
+		rev = rev[:7]
+	}
+	if rev == "" {
+		return "-"
+	}
+	if fp.Modified {
+		rev += "+"
+	}
+	return rev
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func d(v time.Duration) string {
+	switch {
+	case v >= time.Second:
+		return v.Round(time.Millisecond).String()
+	case v >= time.Millisecond:
+		return v.Round(10 * time.Microsecond).String()
+	}
+	return v.Round(time.Microsecond).String()
+}
+
+func mb(bytes float64) string {
+	return fmt.Sprintf("%.1fMB", bytes/(1<<20))
+}
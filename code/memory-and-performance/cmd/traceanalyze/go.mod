@@ -0,0 +1,15 @@
+module github.com/Elvis339/go_gc_eval/cmd/traceanalyze
+
+go 1.26.0
+
+require (
+	github.com/Elvis339/go_gc_eval v0.0.0
+	golang.org/x/exp v0.0.0-20260908205506-85c1c2202aba
+)
+
+require (
+	github.com/arl/statsviz v0.6.0 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
+)
+
+replace github.com/Elvis339/go_gc_eval => ../..
@@ -0,0 +1,250 @@
+// Package gcphase reads a runtime/trace execution trace and reports what the
+// GC did inside the measured region: per cycle STW pauses and mark phase
+// length, mark assist time per goroutine and the share of wall time spent in
+// GC related states.
+package gcphase
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/trace"
+)
+
+const (
+	markPhase  = "GC concurrent mark phase"
+	markAssist = "GC mark assist"
+	stwPrefix  = "stop-the-world ("
+	sweepTerm  = "GC sweep termination"
+	markTerm   = "GC mark termination"
+)
+
+// Cycle is one GC cycle as seen in the trace. Times are relative to the
+// start of the window.
+type Cycle struct {
+	N         int // counted from the start of the trace, not the runtime's GC number
+	Start     time.Duration
+	SweepTerm time.Duration // STW sweep termination
+	Mark      time.Duration // concurrent mark phase, after sweep termination
+	MarkTerm  time.Duration // STW mark termination
+}
+
+// STW returns the total stop-the-world time of the cycle.
+func (c Cycle) STW() time.Duration {
+	return c.SweepTerm + c.MarkTerm
+}
+
+// Assist is the mark assist time of one goroutine.
+type Assist struct {
+	Goroutine int64
+	Time      time.Duration
+	Count     int
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	Region string        // region type used as the window, empty for the whole trace
+	Window time.Duration // wall time of the window
+
+	Cycles  []Cycle
+	Assists []Assist // sorted by time, largest first
+
+	STW        time.Duration // world stopped for any reason
+	GCSTW      time.Duration // world stopped by the GC
+	MarkActive time.Duration // concurrent mark phase running
+	AssistTime time.Duration // goroutine time spent in mark assists, summed over goroutines
+}
+
+// Fraction returns d as a share of the window.
+func (r *Report) Fraction(d time.Duration) float64 {
+	if r.Window == 0 {
+		return 0
+	}
+	return float64(d) / float64(r.Window)
+}
+
+type interval struct {
+	start, end trace.Time
+}
+
+type openKey struct {
+	name  string
+	scope int64
+}
+
+// Analyze reads the trace from rd. When a user region of type region exists
+// its first occurrence bounds the window, otherwise the whole trace is used.
+func Analyze(rd io.Reader, region string) (*Report, error) {
+	r, err := trace.NewReader(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		first, last  trace.Time
+		seen         bool
+		window       *interval
+		open         = make(map[openKey]trace.Time)
+		stw, gcSTW   []interval
+		marks        []interval
+		assists      = make(map[int64][]interval)
+		cycles       []*Cycle
+		cycleStarts  []trace.Time
+		currentCycle *Cycle
+	)
+
+	newCycle := func(at trace.Time) *Cycle {
+		c := &Cycle{N: len(cycles) + 1}
+		cycles = append(cycles, c)
+		cycleStarts = append(cycleStarts, at)
+		return c
+	}
+
+	for {
+		ev, err := r.ReadEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		t := ev.Time()
+		if !seen {
+			first, seen = t, true
+		}
+		last = t
+
+		switch ev.Kind() {
+		case trace.EventRegionBegin:
+			if ev.Region().Type == region && window == nil {
+				window = &interval{start: t, end: -1}
+			}
+		case trace.EventRegionEnd:
+			if ev.Region().Type == region && window != nil && window.end < 0 {
+				window.end = t
+			}
+		case trace.EventRangeBegin, trace.EventRangeActive:
+			rng := ev.Range()
+			open[openKey{rng.Name, scopeID(rng.Scope)}] = t
+			// The runtime opens the mark phase range at the start of the cycle,
+			// before sweep termination stops the world. An active range means
+			// tracing started in the middle of a cycle.
+			if rng.Name == markPhase {
+				currentCycle = newCycle(t)
+			}
+		case trace.EventRangeEnd:
+			rng := ev.Range()
+			key := openKey{rng.Name, scopeID(rng.Scope)}
+			start, ok := open[key]
+			if !ok {
+				continue
+			}
+			delete(open, key)
+			iv := interval{start, t}
+			d := t.Sub(start)
+
+			switch {
+			case rng.Name == markPhase:
+				marks = append(marks, iv)
+				if currentCycle != nil {
+					currentCycle.Mark = d - currentCycle.SweepTerm
+				}
+			case rng.Name == markAssist:
+				g := scopeID(rng.Scope)
+				assists[g] = append(assists[g], iv)
+			case strings.HasPrefix(rng.Name, stwPrefix):
+				stw = append(stw, iv)
+				reason := strings.TrimSuffix(strings.TrimPrefix(rng.Name, stwPrefix), ")")
+				if !strings.HasPrefix(reason, "GC ") {
+					continue
+				}
+				gcSTW = append(gcSTW, iv)
+				if currentCycle == nil {
+					continue
+				}
+				switch reason {
+				case sweepTerm:
+					currentCycle.SweepTerm = d
+				case markTerm:
+					currentCycle.MarkTerm = d
+					currentCycle = nil
+				}
+			}
+		}
+	}
+	if !seen {
+		return nil, fmt.Errorf("empty trace")
+	}
+
+	w := interval{first, last}
+	rep := &Report{}
+	if window != nil {
+		rep.Region = region
+		w.start = window.start
+		if window.end >= 0 {
+			w.end = window.end
+		}
+	}
+	rep.Window = w.end.Sub(w.start)
+
+	for i, c := range cycles {
+		// A cycle belongs to the window it started in.
+		if cycleStarts[i] < w.start || cycleStarts[i] > w.end {
+			continue
+		}
+		c.Start = cycleStarts[i].Sub(w.start)
+		rep.Cycles = append(rep.Cycles, *c)
+	}
+
+	rep.STW = clipped(stw, w)
+	rep.GCSTW = clipped(gcSTW, w)
+	rep.MarkActive = clipped(marks, w)
+	for g, ivs := range assists {
+		a := Assist{Goroutine: g}
+		for _, iv := range ivs {
+			if d := clip(iv, w); d > 0 {
+				a.Time += d
+				a.Count++
+			}
+		}
+		if a.Count > 0 {
+			rep.Assists = append(rep.Assists, a)
+			rep.AssistTime += a.Time
+		}
+	}
+	sort.Slice(rep.Assists, func(i, j int) bool { return rep.Assists[i].Time > rep.Assists[j].Time })
+
+	return rep, nil
+}
+
+func scopeID(s trace.ResourceID) int64 {
+	switch s.Kind {
+	case trace.ResourceGoroutine:
+		return int64(s.Goroutine())
+	case trace.ResourceProc:
+		return int64(s.Proc())
+	case trace.ResourceThread:
+		return int64(s.Thread())
+	}
+	return 0
+}
+
+func clip(iv, w interval) time.Duration {
+	start, end := max(iv.start, w.start), min(iv.end, w.end)
+	if end <= start {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+func clipped(ivs []interval, w interval) time.Duration {
+	var total time.Duration
+	for _, iv := range ivs {
+		total += clip(iv, w)
+	}
+	return total
+}
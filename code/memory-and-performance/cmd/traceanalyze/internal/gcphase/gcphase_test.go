@@ -0,0 +1,78 @@
+package gcphase
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	rtrace "runtime/trace"
+	"testing"
+)
+
+var sink [][]byte
+
+func TestAnalyze(t *testing.T) {
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Skip("tracing unavailable:", err)
+	}
+
+	// Garbage outside the region must not be attributed to it.
+	runtime.GC()
+	rtrace.WithRegion(context.Background(), "measured", func() {
+		for i := 0; i < 1000; i++ {
+			sink = append(sink, make([]byte, 64<<10))
+			if len(sink) > 100 {
+				sink = sink[:0]
+			}
+		}
+		runtime.GC()
+		runtime.GC()
+	})
+	rtrace.Stop()
+
+	rep, err := Analyze(&buf, "measured")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rep.Region != "measured" {
+		t.Errorf("window not bound to the region: %q", rep.Region)
+	}
+	if rep.Window <= 0 {
+		t.Fatalf("window %s", rep.Window)
+	}
+	if len(rep.Cycles) < 2 {
+		t.Fatalf("got %d cycles, want at least the 2 forced ones", len(rep.Cycles))
+	}
+	for _, c := range rep.Cycles {
+		if c.Start < 0 {
+			t.Errorf("cycle %d starts before the region: %+v", c.N, c)
+		}
+	}
+	if rep.GCSTW <= 0 || rep.GCSTW > rep.STW {
+		t.Errorf("gc stw %s, stw %s", rep.GCSTW, rep.STW)
+	}
+	if f := rep.Fraction(rep.MarkActive); f <= 0 || f > 1 {
+		t.Errorf("mark fraction %v", f)
+	}
+}
+
+func TestAnalyzeWholeTrace(t *testing.T) {
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Skip("tracing unavailable:", err)
+	}
+	runtime.GC()
+	rtrace.Stop()
+
+	rep, err := Analyze(&buf, "measured")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Region != "" {
+		t.Errorf("unexpected region %q", rep.Region)
+	}
+	if len(rep.Cycles) == 0 {
+		t.Error("forced GC not found")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/cmd/traceanalyze/internal/gcphase"
+	"github.com/Elvis339/go_gc_eval/internal/harness"
+)
+
+// make run EXEC=graph ARGS="-v ptr-chasing -trace"
+// cd cmd/traceanalyze && go run . ../../traces/graph_ptr-chasing.trace
+func main() {
+	region := flag.String("region", harness.MeasuredRegion, "Region type bounding the analysis, the whole trace is used if it is missing")
+	top := flag.Int("top", 10, "Number of goroutines to list by mark assist time")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <file.trace>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	rep, err := gcphase.Analyze(f, *region)
+	if err != nil {
+		log.Fatalf("%s: %v", flag.Arg(0), err)
+	}
+
+	window := "whole trace"
+	if rep.Region != "" {
+		window = fmt.Sprintf("region %q", rep.Region)
+	}
+	fmt.Printf("Window: %s, %s\n\n", window, rep.Window.Round(time.Microsecond))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "GC\tstart\tsweep term STW\tmark\tmark term STW\tSTW total\t")
+	for _, c := range rep.Cycles {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t\n", c.N, d(c.Start), d(c.SweepTerm), d(c.Mark), d(c.MarkTerm), d(c.STW()))
+	}
+	tw.Flush()
+
+	fmt.Printf("\nMark assists (%d goroutines):\n", len(rep.Assists))
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "goroutine\tassists\ttime\t% of window\t")
+	for i, a := range rep.Assists {
+		if i == *top {
+			fmt.Fprintf(tw, "...\t\t\t\t\n")
+			break
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%.2f%%\t\n", a.Goroutine, a.Count, d(a.Time), rep.Fraction(a.Time)*100)
+	}
+	tw.Flush()
+
+	fmt.Printf("\nShare of the window:\n")
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, row := range []struct {
+		name string
+		v    time.Duration
+	}{
+		{"mark phase active", rep.MarkActive},
+		{"GC stop-the-world", rep.GCSTW},
+		{"any stop-the-world", rep.STW},
+		{"mark assist (goroutine time)", rep.AssistTime},
+	} {
+		fmt.Fprintf(tw, "%s\t%s\t%.2f%%\t\n", row.name, d(row.v), rep.Fraction(row.v)*100)
+	}
+	tw.Flush()
+}
+
+func d(v time.Duration) string {
+	return v.Round(time.Microsecond).String()
+}
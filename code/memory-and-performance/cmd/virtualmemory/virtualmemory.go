@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"syscall"
 	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/harness"
 )
 
 const mb = 1024 * 1024
@@ -38,28 +40,43 @@ func log(pageFaults int, startTime time.Time) {
 
 // make run EXEC=vmem ARGS="-v [fault or call without ARGS]"
 func main() {
+	opts := harness.RegisterFlags(flag.CommandLine)
 	version := flag.String("v", "all", "Version: fault")
 	flag.Parse()
 
+	h, err := harness.New(opts, *version)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := h.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
 	data := make([]byte, mb)
 
-	switch *version {
-	case "fault":
-		fmt.Println("Usage: With Page Fault")
+	_, err = h.Measure(func() {
+		switch *version {
+		case "fault":
+			fmt.Println("Usage: With Page Fault")
 
-		f0 := getPageFaults()
-		start := time.Now()
-		pageFault(data)
-		log(f0, start)
-		return
-	default:
-		f0 := getPageFaults()
-		fmt.Println("Usage: No Page Fault")
-		pageFault(data)
+			f0 := getPageFaults()
+			start := time.Now()
+			pageFault(data)
+			log(f0, start)
+		default:
+			f0 := getPageFaults()
+			fmt.Println("Usage: No Page Fault")
+			pageFault(data)
 
-		f1 := getPageFaults()
-		start := time.Now()
-		noPageFault(data)
-		log(f1-f0, start)
+			f1 := getPageFaults()
+			start := time.Now()
+			noPageFault(data)
+			log(f1-f0, start)
+		}
+	})
+	if err != nil {
+		panic(err)
 	}
 }
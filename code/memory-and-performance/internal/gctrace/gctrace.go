@@ -0,0 +1,212 @@
+// Package gctrace parses the output of GODEBUG=gctrace=1.
+//
+// Each GC cycle is reported by the runtime as a single line:
+//
+//	gc 5 @0.415s 7%: 0.014+107+0.011 ms clock, 0.11+1.0/213/497+0.094 ms cpu, 218->228->184 MB, 256 MB goal, 0 MB stacks, 0 MB globals, 8 P
+//
+// See the runtime package documentation for the meaning of every field.
+package gctrace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock is the wall clock time of each GC phase.
+type Clock struct {
+	SweepTerm time.Duration // STW sweep termination
+	Mark      time.Duration // concurrent mark and scan
+	MarkTerm  time.Duration // STW mark termination
+}
+
+// CPU is the CPU time of each GC phase, the mark phase is split by worker type.
+type CPU struct {
+	SweepTerm  time.Duration // STW sweep termination
+	Assist     time.Duration // mutator assists during mark
+	Background time.Duration // dedicated and fractional background workers
+	Idle       time.Duration // idle GC workers
+	MarkTerm   time.Duration // STW mark termination
+}
+
+// Total returns the CPU time spent over all phases.
+func (c CPU) Total() time.Duration {
+	return c.SweepTerm + c.Assist + c.Background + c.Idle + c.MarkTerm
+}
+
+// Record is one parsed gctrace line. Heap sizes are in MB, as printed.
+type Record struct {
+	Cycle      int           // GC number, incremented at each GC
+	At         time.Duration // time since program start
+	GCPercent  int           // percentage of time spent in GC since program start
+	Clock      Clock
+	CPU        CPU
+	HeapBefore float64 // heap size at GC start
+	HeapAfter  float64 // heap size at GC end
+	HeapLive   float64 // live heap after marking
+	HeapGoal   float64
+	Stacks     float64 // scannable stack size, Go 1.18+
+	Globals    float64 // scannable globals size, Go 1.18+
+	Procs      int     // number of processors used
+	Forced     bool    // runtime.GC() or debug.FreeOSMemory()
+}
+
+// STW returns the wall time the world was stopped in this cycle.
+func (r Record) STW() time.Duration {
+	return r.Clock.SweepTerm + r.Clock.MarkTerm
+}
+
+// Parse reads every gctrace line from r. Anything that is not a GC line, like
+// program output or scavenger lines, is skipped.
+func Parse(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "gc ") {
+			continue
+		}
+		rec, err := ParseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// ParseFile is Parse for a file on disk.
+func ParseFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return records, nil
+}
+
+// ParseLine parses a single gctrace line.
+func ParseLine(line string) (Record, error) {
+	var rec Record
+
+	line = strings.TrimSpace(line)
+	if strings.HasSuffix(line, "(forced)") {
+		rec.Forced = true
+		line = strings.TrimSpace(strings.TrimSuffix(line, "(forced)"))
+	}
+
+	head, body, ok := strings.Cut(line, ":")
+	if !ok {
+		return rec, fmt.Errorf("missing ':' in %q", line)
+	}
+
+	// gc # @#s #%
+	f := strings.Fields(head)
+	if len(f) != 4 || f[0] != "gc" {
+		return rec, fmt.Errorf("malformed header %q", head)
+	}
+	var err error
+	if rec.Cycle, err = strconv.Atoi(f[1]); err != nil {
+		return rec, fmt.Errorf("cycle: %w", err)
+	}
+	if rec.At, err = time.ParseDuration(strings.TrimPrefix(f[2], "@")); err != nil {
+		return rec, fmt.Errorf("timestamp: %w", err)
+	}
+	if rec.GCPercent, err = strconv.Atoi(strings.TrimSuffix(f[3], "%")); err != nil {
+		return rec, fmt.Errorf("gc percent: %w", err)
+	}
+
+	for _, part := range strings.Split(body, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasSuffix(part, " ms clock"):
+			v, err := msList(strings.TrimSuffix(part, " ms clock"), "+", 3)
+			if err != nil {
+				return rec, fmt.Errorf("clock: %w", err)
+			}
+			rec.Clock = Clock{SweepTerm: v[0], Mark: v[1], MarkTerm: v[2]}
+		case strings.HasSuffix(part, " ms cpu"):
+			phases := strings.Split(strings.TrimSuffix(part, " ms cpu"), "+")
+			if len(phases) != 3 {
+				return rec, fmt.Errorf("malformed cpu %q", part)
+			}
+			stw, err := msList(phases[0]+"+"+phases[2], "+", 2)
+			if err != nil {
+				return rec, fmt.Errorf("cpu: %w", err)
+			}
+			mark, err := msList(phases[1], "/", 3)
+			if err != nil {
+				return rec, fmt.Errorf("cpu mark: %w", err)
+			}
+			rec.CPU = CPU{SweepTerm: stw[0], Assist: mark[0], Background: mark[1], Idle: mark[2], MarkTerm: stw[1]}
+		case strings.HasSuffix(part, " MB goal"):
+			if rec.HeapGoal, err = mb(part, " MB goal"); err != nil {
+				return rec, err
+			}
+		case strings.HasSuffix(part, " MB stacks"):
+			if rec.Stacks, err = mb(part, " MB stacks"); err != nil {
+				return rec, err
+			}
+		case strings.HasSuffix(part, " MB globals"):
+			if rec.Globals, err = mb(part, " MB globals"); err != nil {
+				return rec, err
+			}
+		case strings.HasSuffix(part, " MB") && strings.Contains(part, "->"):
+			heap := strings.Split(strings.TrimSuffix(part, " MB"), "->")
+			if len(heap) != 3 {
+				return rec, fmt.Errorf("malformed heap %q", part)
+			}
+			var v [3]float64
+			for i, s := range heap {
+				if v[i], err = strconv.ParseFloat(s, 64); err != nil {
+					return rec, fmt.Errorf("heap: %w", err)
+				}
+			}
+			rec.HeapBefore, rec.HeapAfter, rec.HeapLive = v[0], v[1], v[2]
+		case strings.HasSuffix(part, " P"):
+			if rec.Procs, err = strconv.Atoi(strings.TrimSuffix(part, " P")); err != nil {
+				return rec, fmt.Errorf("procs: %w", err)
+			}
+		default:
+			return rec, fmt.Errorf("unknown field %q", part)
+		}
+	}
+
+	return rec, nil
+}
+
+// msList parses n sep separated millisecond values.
+func msList(s, sep string, n int) ([]time.Duration, error) {
+	parts := strings.Split(s, sep)
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d values in %q", n, s)
+	}
+	out := make([]time.Duration, n)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = time.Duration(v * float64(time.Millisecond))
+	}
+	return out, nil
+}
+
+func mb(part, suffix string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(part, suffix), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", strings.TrimSpace(suffix), err)
+	}
+	return v, nil
+}
@@ -0,0 +1,114 @@
+package gctrace
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const trace = `Configuration:
+  Implementation: compact
+gc 1 @0.000s 23%: 0.007+8.8+0.014 ms clock, 0.061+0.076/17/16+0.11 ms cpu, 62->68->68 MB, 62 MB goal, 0 MB stacks, 0 MB globals, 8 P
+gc 2 @0.030s 8%: 0.025+3.6+0.010 ms clock, 0.20+0.11/6.5/13+0.084 ms cpu, 124->130->130 MB, 137 MB goal, 0 MB stacks, 0 MB globals, 8 P
+gc 3 @0.216s 2%: 0.032+10+0.009 ms clock, 0.26+0/20/0.10+0.077 ms cpu, 246->253->194 MB, 261 MB goal, 0 MB stacks, 0 MB globals, 8 P
+Execution time 1.8s
+gc 4 @1.820s 0%: 0.064+0.36+0.006 ms clock, 0.51+0/0.25/0.33+0.053 ms cpu, 371->371->64 MB, 490 MB goal, 1 MB stacks, 2 MB globals, 8 P (forced)
+`
+
+func ms(v float64) time.Duration {
+	return time.Duration(v * float64(time.Millisecond))
+}
+
+func TestParseLine(t *testing.T) {
+	rec, err := ParseLine("gc 4 @1.820s 0%: 0.064+0.36+0.006 ms clock, 0.51+0/0.25/0.33+0.053 ms cpu, 371->371->64 MB, 490 MB goal, 1 MB stacks, 2 MB globals, 8 P (forced)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Record{
+		Cycle:      4,
+		At:         1820 * time.Millisecond,
+		GCPercent:  0,
+		Clock:      Clock{SweepTerm: ms(0.064), Mark: ms(0.36), MarkTerm: ms(0.006)},
+		CPU:        CPU{SweepTerm: ms(0.51), Assist: 0, Background: ms(0.25), Idle: ms(0.33), MarkTerm: ms(0.053)},
+		HeapBefore: 371,
+		HeapAfter:  371,
+		HeapLive:   64,
+		HeapGoal:   490,
+		Stacks:     1,
+		Globals:    2,
+		Procs:      8,
+		Forced:     true,
+	}
+	if rec != want {
+		t.Fatalf("got %+v\nwant %+v", rec, want)
+	}
+}
+
+func TestParseLineWithoutStacksAndGlobals(t *testing.T) {
+	// Go 1.17 and older do not print stacks and globals.
+	rec, err := ParseLine("gc 1 @0.012s 2%: 0.016+1.2+0.031 ms clock, 0.13+0.35/1.1/0.91+0.25 ms cpu, 4->4->0 MB, 5 MB goal, 8 P")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.HeapGoal != 5 || rec.Procs != 8 || rec.Forced {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+}
+
+func TestParseLineMalformed(t *testing.T) {
+	for _, line := range []string{
+		"gc 1 @0.012s 2% 0.016+1.2+0.031 ms clock",
+		"gc x @0.012s 2%: 0.016+1.2+0.031 ms clock",
+		"gc 1 @0.012s 2%: 0.016+1.2 ms clock",
+		"gc 1 @0.012s 2%: 0.13+0.35/1.1+0.25 ms cpu",
+		"gc 1 @0.012s 2%: 4->4 MB",
+		"gc 1 @0.012s 2%: 12 widgets",
+	} {
+		if _, err := ParseLine(line); err == nil {
+			t.Errorf("ParseLine(%q) succeeded, want error", line)
+		}
+	}
+}
+
+func TestParseSkipsProgramOutput(t *testing.T) {
+	records, err := Parse(strings.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+	for i, r := range records {
+		if r.Cycle != i+1 {
+			t.Errorf("record %d has cycle %d", i, r.Cycle)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	records, err := Parse(strings.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Summarize(records)
+
+	if s.Cycles != 4 || s.Forced != 1 {
+		t.Errorf("cycles=%d forced=%d", s.Cycles, s.Forced)
+	}
+	if s.WorstMark != ms(10) || s.WorstMarkCycle != 3 {
+		t.Errorf("worst mark %s (GC %d)", s.WorstMark, s.WorstMarkCycle)
+	}
+	if s.MaxGCPercent != 23 || s.MaxGCPercentCycle != 1 {
+		t.Errorf("max gc%% %d (GC %d)", s.MaxGCPercent, s.MaxGCPercentCycle)
+	}
+	if s.PeakCPU != ms(20) || s.PeakCPUCycle != 3 {
+		t.Errorf("peak cpu %s (GC %d)", s.PeakCPU, s.PeakCPUCycle)
+	}
+	if s.HeapStart != 62 || s.PeakHeap != 371 || s.PeakHeapCycle != 4 {
+		t.Errorf("heap %v->%v (GC %d)", s.HeapStart, s.PeakHeap, s.PeakHeapCycle)
+	}
+	if s.FinalGCPercent != 0 {
+		t.Errorf("final gc%% %d", s.FinalGCPercent)
+	}
+}
@@ -0,0 +1,65 @@
+package gctrace
+
+import "time"
+
+// Summary holds the per-run peaks used to compare GC implementations, see
+// the "Peaks" table in the Garbage Collection Pt. 2 post.
+type Summary struct {
+	Cycles int
+	Forced int
+
+	// Worst Marking Time: longest concurrent mark phase (wall clock).
+	WorstMark      time.Duration
+	WorstMarkCycle int
+
+	// Highest GC %: largest share of time spent in GC since program start.
+	MaxGCPercent      int
+	MaxGCPercentCycle int
+
+	// Peak CPU Overhead: largest CPU time of a single phase or mark worker class.
+	PeakCPU      time.Duration
+	PeakCPUCycle int
+
+	// Max Heap Growth: heap at the first GC start up to the largest heap at GC end.
+	HeapStart     float64
+	PeakHeap      float64
+	PeakHeapCycle int
+
+	TotalSTW       time.Duration // sum of sweep and mark termination pauses
+	TotalCPU       time.Duration // sum of GC CPU time over all phases
+	FinalGCPercent int           // GC % reported by the last cycle
+}
+
+// Summarize computes the Summary of records, which are expected in cycle order.
+func Summarize(records []Record) Summary {
+	var s Summary
+	s.Cycles = len(records)
+	if len(records) == 0 {
+		return s
+	}
+	s.HeapStart = records[0].HeapBefore
+	s.FinalGCPercent = records[len(records)-1].GCPercent
+
+	for _, r := range records {
+		if r.Forced {
+			s.Forced++
+		}
+		if r.Clock.Mark > s.WorstMark {
+			s.WorstMark, s.WorstMarkCycle = r.Clock.Mark, r.Cycle
+		}
+		if r.GCPercent > s.MaxGCPercent || s.MaxGCPercentCycle == 0 {
+			s.MaxGCPercent, s.MaxGCPercentCycle = r.GCPercent, r.Cycle
+		}
+		for _, c := range []time.Duration{r.CPU.SweepTerm, r.CPU.Assist, r.CPU.Background, r.CPU.Idle, r.CPU.MarkTerm} {
+			if c > s.PeakCPU {
+				s.PeakCPU, s.PeakCPUCycle = c, r.Cycle
+			}
+		}
+		if r.HeapAfter > s.PeakHeap {
+			s.PeakHeap, s.PeakHeapCycle = r.HeapAfter, r.Cycle
+		}
+		s.TotalSTW += r.STW()
+		s.TotalCPU += r.CPU.Total()
+	}
+	return s
+}
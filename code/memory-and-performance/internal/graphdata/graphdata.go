@@ -0,0 +1,362 @@
+// Package graphdata loads directed graphs from the common on-disk formats
+// into a flat edge list over dense node IDs, which every layout in cmd/graph
+// is built from:
+//
+//   - SNAP edge lists: "from to" per line, "#" comments, arbitrary IDs
+//   - Matrix Market coordinate files (.mtx), 1-based, general or symmetric
+//   - DIMACS shortest path files (.gr): "p sp n m" and "a from to weight"
+//
+// Files ending in .gz are decompressed on the fly.
+package graphdata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Edges is a directed graph with nodes 0..Nodes-1.
+type Edges struct {
+	Nodes    int
+	Src, Dst []int32
+	Weights  []float64 // nil when the input has none
+
+	// IDs maps a node back to its ID in the input file.
+	IDs []int64
+}
+
+// Len returns the number of edges.
+func (e *Edges) Len() int {
+	return len(e.Src)
+}
+
+// Add appends the edge from -> to.
+func (e *Edges) Add(from, to int32) {
+	e.Src = append(e.Src, from)
+	e.Dst = append(e.Dst, to)
+}
+
+// Degrees returns the out-degree of every node.
+func (e *Edges) Degrees() []int32 {
+	deg := make([]int32, e.Nodes)
+	for _, s := range e.Src {
+		deg[s]++
+	}
+	return deg
+}
+
+// Format is an input file format.
+type Format string
+
+const (
+	SNAP         Format = "snap"
+	MatrixMarket Format = "mtx"
+	DIMACS       Format = "dimacs"
+)
+
+// FormatOf guesses the format from the file name: .mtx is Matrix Market,
+// .gr and .dimacs are DIMACS, everything else is a SNAP edge list.
+func FormatOf(path string) Format {
+	path = strings.TrimSuffix(path, ".gz")
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mtx":
+		return MatrixMarket
+	case ".gr", ".dimacs":
+		return DIMACS
+	}
+	return SNAP
+}
+
+// Load reads the graph at path in the format given by FormatOf.
+func Load(path string) (*Edges, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	e, err := Read(r, FormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return e, nil
+}
+
+// Read parses a graph in the given format.
+func Read(r io.Reader, format Format) (*Edges, error) {
+	switch format {
+	case SNAP:
+		return ReadSNAP(r)
+	case MatrixMarket:
+		return ReadMatrixMarket(r)
+	case DIMACS:
+		return ReadDIMACS(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// remapper assigns dense IDs in order of first appearance.
+type remapper struct {
+	ids  map[int64]int32
+	orig []int64
+}
+
+func (m *remapper) id(v int64) (int32, error) {
+	if id, ok := m.ids[v]; ok {
+		return id, nil
+	}
+	if len(m.orig) == math.MaxInt32 {
+		return 0, fmt.Errorf("more than %d nodes", math.MaxInt32)
+	}
+	id := int32(len(m.orig))
+	m.ids[v] = id
+	m.orig = append(m.orig, v)
+	return id, nil
+}
+
+// ReadSNAP parses a whitespace separated edge list. IDs may be sparse or
+// start anywhere; node 0 is the first ID in the file. An optional third
+// column is read as the edge weight.
+func ReadSNAP(r io.Reader) (*Edges, error) {
+	m := &remapper{ids: make(map[int64]int32)}
+	e := &Edges{}
+	weighted := false
+
+	err := scanLines(r, func(line int, fields []string) error {
+		if fields[0][0] == '#' || fields[0][0] == '%' {
+			return nil
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("line %d: want \"from to\"", line)
+		}
+		var ends [2]int32
+		for i := range ends {
+			v, err := strconv.ParseInt(fields[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if ends[i], err = m.id(v); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if len(fields) >= 3 && (weighted || e.Len() == 0) {
+			w, err := strconv.ParseFloat(fields[2], 64)
+			if err == nil {
+				weighted = true
+				e.Weights = append(e.Weights, w)
+			}
+		}
+		e.Add(ends[0], ends[1])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if weighted && len(e.Weights) != e.Len() {
+		e.Weights = nil // only some lines had a weight
+	}
+	e.Nodes, e.IDs = len(m.orig), m.orig
+	return e, nil
+}
+
+// ReadMatrixMarket parses a coordinate Matrix Market file. Row i and column
+// j of an entry become the edge i-1 -> j-1; symmetric and skew-symmetric
+// matrices also get j-1 -> i-1 for off-diagonal entries.
+func ReadMatrixMarket(r io.Reader) (*Edges, error) {
+	e := &Edges{}
+	var (
+		header    bool
+		size      bool
+		symmetric bool
+		pattern   bool
+	)
+
+	err := scanLines(r, func(line int, fields []string) error {
+		if !header {
+			// %%MatrixMarket matrix coordinate <field> <symmetry>
+			if len(fields) < 5 || !strings.EqualFold(fields[0], "%%MatrixMarket") {
+				return fmt.Errorf("line %d: missing %%%%MatrixMarket header", line)
+			}
+			if !strings.EqualFold(fields[1], "matrix") || !strings.EqualFold(fields[2], "coordinate") {
+				return fmt.Errorf("line %d: only coordinate matrices are graphs", line)
+			}
+			pattern = strings.EqualFold(fields[3], "pattern")
+			switch strings.ToLower(fields[4]) {
+			case "general":
+			case "symmetric", "skew-symmetric", "hermitian":
+				symmetric = true
+			default:
+				return fmt.Errorf("line %d: unknown symmetry %q", line, fields[4])
+			}
+			header = true
+			return nil
+		}
+		if fields[0][0] == '%' {
+			return nil
+		}
+		if !size {
+			if len(fields) < 3 {
+				return fmt.Errorf("line %d: want \"rows cols entries\"", line)
+			}
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 != nil || err2 != nil || rows < 0 || cols < 0 {
+				return fmt.Errorf("line %d: bad size line", line)
+			}
+			e.Nodes = max(rows, cols)
+			if e.Nodes > math.MaxInt32 {
+				return fmt.Errorf("line %d: more than %d nodes", line, math.MaxInt32)
+			}
+			size = true
+			return nil
+		}
+
+		if len(fields) < 2 {
+			return fmt.Errorf("line %d: want \"row col [value]\"", line)
+		}
+		from, err := index(fields[0], e.Nodes)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		to, err := index(fields[1], e.Nodes)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		w := 1.0
+		if !pattern && len(fields) >= 3 {
+			if w, err = strconv.ParseFloat(fields[2], 64); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		e.Add(from, to)
+		if !pattern {
+			e.Weights = append(e.Weights, w)
+		}
+		if symmetric && from != to {
+			e.Add(to, from)
+			if !pattern {
+				e.Weights = append(e.Weights, w)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !size {
+		return nil, fmt.Errorf("missing size line")
+	}
+	e.IDs = oneBased(e.Nodes)
+	return e, nil
+}
+
+// ReadDIMACS parses a DIMACS shortest path challenge file: "c" comments, one
+// "p sp <nodes> <arcs>" problem line and "a <from> <to> <weight>" arcs with
+// 1-based node IDs.
+func ReadDIMACS(r io.Reader) (*Edges, error) {
+	e := &Edges{}
+	problem := false
+
+	err := scanLines(r, func(line int, fields []string) error {
+		switch fields[0] {
+		case "c":
+			return nil
+		case "p":
+			if len(fields) < 4 {
+				return fmt.Errorf("line %d: want \"p sp nodes arcs\"", line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 || n > math.MaxInt32 {
+				return fmt.Errorf("line %d: bad node count %q", line, fields[2])
+			}
+			e.Nodes = n
+			problem = true
+		case "a", "e":
+			if !problem {
+				return fmt.Errorf("line %d: arc before the problem line", line)
+			}
+			if len(fields) < 3 {
+				return fmt.Errorf("line %d: want \"a from to [weight]\"", line)
+			}
+			from, err := index(fields[1], e.Nodes)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			to, err := index(fields[2], e.Nodes)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			w := 1.0
+			if len(fields) >= 4 {
+				if w, err = strconv.ParseFloat(fields[3], 64); err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+			}
+			e.Add(from, to)
+			e.Weights = append(e.Weights, w)
+		default:
+			return fmt.Errorf("line %d: unknown line type %q", line, fields[0])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !problem {
+		return nil, fmt.Errorf("missing problem line")
+	}
+	e.IDs = oneBased(e.Nodes)
+	return e, nil
+}
+
+// index parses a 1-based node ID.
+func index(s string, n int) (int32, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if v < 1 || v > n {
+		return 0, fmt.Errorf("node %d out of range 1..%d", v, n)
+	}
+	return int32(v - 1), nil
+}
+
+func oneBased(n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	return ids
+}
+
+func scanLines(r io.Reader, fn func(line int, fields []string) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if err := fn(line, fields); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,193 @@
+package graphdata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadSNAP(t *testing.T) {
+	e, err := ReadSNAP(strings.NewReader("# Directed graph\n# FromNodeId\tToNodeId\n1000\t7\n7\t42\n\n42\t1000\n5 7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Nodes != 4 || e.Len() != 4 {
+		t.Fatalf("got %d nodes, %d edges", e.Nodes, e.Len())
+	}
+	if !slices.Equal(e.IDs, []int64{1000, 7, 42, 5}) {
+		t.Errorf("ids %v", e.IDs)
+	}
+	if !slices.Equal(e.Src, []int32{0, 1, 2, 3}) || !slices.Equal(e.Dst, []int32{1, 2, 0, 1}) {
+		t.Errorf("edges %v -> %v", e.Src, e.Dst)
+	}
+	if e.Weights != nil {
+		t.Errorf("unexpected weights %v", e.Weights)
+	}
+
+	e, err = ReadSNAP(strings.NewReader("1 2 0.5\n2 1 1.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(e.Weights, []float64{0.5, 1.5}) {
+		t.Errorf("weights %v", e.Weights)
+	}
+
+	if _, err := ReadSNAP(strings.NewReader("1\n")); err == nil {
+		t.Error("single column accepted")
+	}
+}
+
+func TestReadMatrixMarket(t *testing.T) {
+	in := `%%MatrixMarket matrix coordinate real symmetric
+% comment
+3 3 3
+1 2 2.5
+2 3 1
+3 3 4
+`
+	e, err := ReadMatrixMarket(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Nodes != 3 {
+		t.Fatalf("got %d nodes", e.Nodes)
+	}
+	// Off-diagonal entries are mirrored, the diagonal is not.
+	if !slices.Equal(e.Src, []int32{0, 1, 1, 2, 2}) || !slices.Equal(e.Dst, []int32{1, 0, 2, 1, 2}) {
+		t.Errorf("edges %v -> %v", e.Src, e.Dst)
+	}
+	if !slices.Equal(e.Weights, []float64{2.5, 2.5, 1, 1, 4}) {
+		t.Errorf("weights %v", e.Weights)
+	}
+
+	for _, bad := range []string{
+		"3 3 1\n1 2\n",
+		"%%MatrixMarket matrix array real general\n3 3\n",
+		"%%MatrixMarket matrix coordinate pattern general\n2 2 1\n1 3\n",
+	} {
+		if _, err := ReadMatrixMarket(strings.NewReader(bad)); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestReadDIMACS(t *testing.T) {
+	in := "c 9th DIMACS\np sp 3 2\na 1 2 7\na 3 1 2\n"
+	e, err := ReadDIMACS(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Nodes != 3 || !slices.Equal(e.Src, []int32{0, 2}) || !slices.Equal(e.Dst, []int32{1, 0}) {
+		t.Errorf("got %d nodes, edges %v -> %v", e.Nodes, e.Src, e.Dst)
+	}
+	if !slices.Equal(e.Weights, []float64{7, 2}) {
+		t.Errorf("weights %v", e.Weights)
+	}
+	if _, err := ReadDIMACS(strings.NewReader("a 1 2 3\n")); err == nil {
+		t.Error("arc without problem line accepted")
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]Format{
+		"web-Google.txt.gz": SNAP,
+		"roadNet-CA.txt":    SNAP,
+		"bcsstk17.mtx":      MatrixMarket,
+		"bcsstk17.MTX.gz":   MatrixMarket,
+		"USA-road-d.NY.gr":  DIMACS,
+	} {
+		if got := FormatOf(path); got != want {
+			t.Errorf("%s: got %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestCSRWeights(t *testing.T) {
+	e := &Edges{Nodes: 3}
+	for _, edge := range [][2]int32{{2, 0}, {0, 1}, {2, 1}, {0, 2}} {
+		e.Add(edge[0], edge[1])
+	}
+	offsets, edges, err := e.CSR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := e.CSRWeights(offsets); w != nil {
+		t.Errorf("unweighted edges got weights %v", w)
+	}
+
+	e.Weights = []float64{1, 2, 3, 4}
+	w := e.CSRWeights(offsets)
+	if !slices.Equal(edges, []int32{1, 2, 0, 1}) || !slices.Equal(w, []float64{2, 4, 1, 3}) {
+		t.Errorf("edges %v weights %v", edges, w)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	e := &Edges{Nodes: 5}
+	for _, edge := range [][2]int32{{3, 1}, {0, 4}, {3, 0}, {0, 2}, {4, 4}} {
+		e.Add(edge[0], edge[1])
+	}
+	path := filepath.Join(t.TempDir(), "g.snap")
+	if err := WriteSnapshot(path, e); err != nil {
+		t.Fatal(err)
+	}
+
+	wantOffsets, wantEdges := []int32{0, 2, 2, 2, 4, 5}, []int32{4, 2, 1, 0, 4}
+	load := map[string]func(string) (*Snapshot, error){"read": ReadSnapshot}
+	if runtime.GOOS != "windows" && runtime.GOOS != "plan9" && runtime.GOOS != "js" && runtime.GOOS != "wasip1" {
+		load["map"] = MapSnapshot
+	}
+	for name, fn := range load {
+		s, err := fn(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !slices.Equal(s.Offsets, wantOffsets) || !slices.Equal(s.Edges, wantEdges) {
+			t.Errorf("%s: offsets %v edges %v", name, s.Offsets, s.Edges)
+		}
+		if s.Mapped != (name == "map") {
+			t.Errorf("%s: mapped %t", name, s.Mapped)
+		}
+		back := s.EdgeList()
+		if back.Nodes != 5 || !slices.Equal(back.Src, []int32{0, 0, 3, 3, 4}) || !slices.Equal(back.Dst, wantEdges) {
+			t.Errorf("%s: edge list %v -> %v", name, back.Src, back.Dst)
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("%s: close: %v", name, err)
+		}
+	}
+}
+
+func TestSnapshotRejectsCorruption(t *testing.T) {
+	e := &Edges{Nodes: 3}
+	e.Add(0, 1)
+	e.Add(1, 2)
+	path := filepath.Join(t.TempDir(), "g.snap")
+	if err := WriteSnapshot(path, e); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := slices.Clone(b)
+	flipped[len(b)-6] ^= 1
+	if err := os.WriteFile(path, flipped, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSnapshot(path); !errors.Is(err, ErrChecksum) {
+		t.Errorf("flipped bit: %v, want ErrChecksum", err)
+	}
+
+	if err := os.WriteFile(path, b[:len(b)-4], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSnapshot(path); err == nil {
+		t.Error("truncated snapshot accepted")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package graphdata
+
+import "errors"
+
+// MapSnapshot is not supported on this platform, use ReadSnapshot.
+func MapSnapshot(path string) (*Snapshot, error) {
+	return nil, errors.New("mapping a snapshot is only supported on unix")
+}
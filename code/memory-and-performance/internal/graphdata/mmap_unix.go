@@ -0,0 +1,55 @@
+//go:build unix
+
+package graphdata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// MapSnapshot maps the snapshot at path read-only and uses it in place: the
+// graph lives outside the Go heap, so it neither counts towards the heap goal
+// nor gets scanned. The checksum is verified, which faults every page in.
+func MapSnapshot(path string) (*Snapshot, error) {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		return nil, errors.New("mapping a snapshot needs a little endian machine, use ReadSnapshot")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < headerSize || fi.Size() != int64(int(fi.Size())) {
+		return nil, fmt.Errorf("%s: not a graph snapshot", path)
+	}
+
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("%s: mmap: %w", path, err)
+	}
+	nodes, edges, err := checkSnapshot(b)
+	if err != nil {
+		syscall.Munmap(b)
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	offsets := unsafe.Slice((*int32)(unsafe.Pointer(&b[headerSize])), nodes+1)
+	s := &Snapshot{
+		Offsets: offsets,
+		Mapped:  true,
+		unmap:   func() error { return syscall.Munmap(b) },
+	}
+	if edges > 0 {
+		s.Edges = unsafe.Slice((*int32)(unsafe.Pointer(&b[headerSize+4*(nodes+1)])), edges)
+	}
+	return s, nil
+}
@@ -0,0 +1,187 @@
+package graphdata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+)
+
+// A snapshot is a graph already laid out as CSR, so loading it is a read or
+// an mmap instead of parsing and sorting an edge list. All values are little
+// endian:
+//
+//	header   magic "GRAPHSNP", version uint32, reserved uint32,
+//	         nodes uint64, edges uint64                           32 bytes
+//	offsets  (nodes+1) × int32, node i's edges are edges[offsets[i]:offsets[i+1]]
+//	edges    edges × int32 target nodes
+//	checksum CRC-32C of everything before it, uint32
+//
+// Every section starts 4-byte aligned, so a mapped file can be used as
+// []int32 in place.
+
+const (
+	snapshotMagic   = "GRAPHSNP"
+	snapshotVersion = 1
+	headerSize      = 32
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrChecksum is returned for a snapshot whose contents do not match its
+// checksum.
+var ErrChecksum = errors.New("snapshot checksum mismatch")
+
+// Snapshot is a graph in CSR form loaded from a snapshot file.
+type Snapshot struct {
+	Offsets []int32
+	Edges   []int32
+
+	// Mapped is true when Offsets and Edges point into an mmapped file
+	// outside the Go heap; they are invalid after Close.
+	Mapped bool
+	unmap  func() error
+}
+
+// Nodes returns the number of nodes.
+func (s *Snapshot) Nodes() int {
+	return len(s.Offsets) - 1
+}
+
+// Close unmaps a mapped snapshot, it is a no-op otherwise.
+func (s *Snapshot) Close() error {
+	if s.unmap == nil {
+		return nil
+	}
+	err := s.unmap()
+	s.unmap, s.Offsets, s.Edges = nil, nil, nil
+	return err
+}
+
+// EdgeList expands the snapshot back to an edge list, in CSR order.
+func (s *Snapshot) EdgeList() *Edges {
+	e := &Edges{
+		Nodes: s.Nodes(),
+		Src:   make([]int32, 0, len(s.Edges)),
+		Dst:   append([]int32(nil), s.Edges...),
+	}
+	for i := 0; i < e.Nodes; i++ {
+		for j := s.Offsets[i]; j < s.Offsets[i+1]; j++ {
+			e.Src = append(e.Src, int32(i))
+		}
+	}
+	return e
+}
+
+// CSR returns e as offsets and edges, each node's edges in input order. It
+// fails for more than math.MaxInt32 edges, past what int32 offsets address.
+func (e *Edges) CSR() (offsets, edges []int32, err error) {
+	if e.Len() > math.MaxInt32 {
+		return nil, nil, fmt.Errorf("%d edges, more than %d", e.Len(), math.MaxInt32)
+	}
+	offsets = make([]int32, e.Nodes+1)
+	for i, d := range e.Degrees() {
+		offsets[i+1] = offsets[i] + d
+	}
+
+	// Counting sort by source keeps the input order of each node's edges.
+	edges = make([]int32, e.Len())
+	next := append([]int32(nil), offsets[:e.Nodes]...)
+	for i, s := range e.Src {
+		edges[next[s]] = e.Dst[i]
+		next[s]++
+	}
+	return offsets, edges, nil
+}
+
+// CSRWeights returns e.Weights in the order of the edges CSR returned with
+// offsets, nil when e has no weights.
+func (e *Edges) CSRWeights(offsets []int32) []float64 {
+	if e.Weights == nil {
+		return nil
+	}
+	weights := make([]float64, e.Len())
+	next := append([]int32(nil), offsets[:e.Nodes]...)
+	for i, s := range e.Src {
+		weights[next[s]] = e.Weights[i]
+		next[s]++
+	}
+	return weights
+}
+
+// WriteSnapshot writes e to path in the snapshot format. Weights and the
+// original node IDs are not stored.
+func WriteSnapshot(path string, e *Edges) error {
+	offsets, edges, err := e.CSR()
+	if err != nil {
+		return err
+	}
+	b := make([]byte, snapshotSize(e.Nodes, len(edges)))
+	copy(b, snapshotMagic)
+	binary.LittleEndian.PutUint32(b[8:], snapshotVersion)
+	binary.LittleEndian.PutUint64(b[16:], uint64(e.Nodes))
+	binary.LittleEndian.PutUint64(b[24:], uint64(len(edges)))
+	p := b[headerSize:]
+	for _, v := range offsets {
+		binary.LittleEndian.PutUint32(p, uint32(v))
+		p = p[4:]
+	}
+	for _, v := range edges {
+		binary.LittleEndian.PutUint32(p, uint32(v))
+		p = p[4:]
+	}
+	binary.LittleEndian.PutUint32(p, crc32.Checksum(b[:len(b)-4], castagnoli))
+	return os.WriteFile(path, b, 0o644)
+}
+
+// ReadSnapshot reads the snapshot at path into the Go heap.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes, edges, err := checkSnapshot(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s := &Snapshot{
+		Offsets: make([]int32, nodes+1),
+		Edges:   make([]int32, edges),
+	}
+	p := b[headerSize:]
+	for i := range s.Offsets {
+		s.Offsets[i] = int32(binary.LittleEndian.Uint32(p))
+		p = p[4:]
+	}
+	for i := range s.Edges {
+		s.Edges[i] = int32(binary.LittleEndian.Uint32(p))
+		p = p[4:]
+	}
+	return s, nil
+}
+
+func snapshotSize(nodes, edges int) int {
+	return headerSize + 4*(nodes+1) + 4*edges + 4
+}
+
+// checkSnapshot validates the header, the size and the checksum of b and
+// returns the node and edge counts. Verifying the checksum reads every page.
+func checkSnapshot(b []byte) (nodes, edges int, err error) {
+	if len(b) < headerSize+8 || string(b[:8]) != snapshotMagic {
+		return 0, 0, errors.New("not a graph snapshot")
+	}
+	if v := binary.LittleEndian.Uint32(b[8:]); v != snapshotVersion {
+		return 0, 0, fmt.Errorf("unsupported snapshot version %d", v)
+	}
+	n, m := binary.LittleEndian.Uint64(b[16:]), binary.LittleEndian.Uint64(b[24:])
+	if n >= 1<<31 || m >= 1<<31 || snapshotSize(int(n), int(m)) != len(b) {
+		return 0, 0, fmt.Errorf("%d nodes and %d edges do not match the size of %d bytes", n, m, len(b))
+	}
+	if crc32.Checksum(b[:len(b)-4], castagnoli) != binary.LittleEndian.Uint32(b[len(b)-4:]) {
+		return 0, 0, ErrChecksum
+	}
+	return int(n), int(m), nil
+}
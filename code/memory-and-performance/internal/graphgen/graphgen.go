@@ -0,0 +1,212 @@
+// Package graphgen generates synthetic directed graphs from an explicit seed.
+// The same Config always yields the same edge list, whatever the layout,
+// build or Go version, so every variant of an experiment traverses exactly
+// the same graph.
+package graphgen
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/Elvis339/go_gc_eval/internal/graphdata"
+)
+
+// Topology selects a generator.
+type Topology string
+
+const (
+	// Uniform gives every node 1..Degree out-edges to uniformly random
+	// targets, the graph cmd/graph always used.
+	Uniform Topology = "uniform"
+	// ErdosRenyi draws Nodes*Degree edges with uniformly random endpoints.
+	ErdosRenyi Topology = "er"
+	// BarabasiAlbert adds nodes one at a time, each linking to Degree
+	// existing nodes chosen proportionally to their degree, which gives a
+	// power-law degree distribution. Edges go both ways.
+	BarabasiAlbert Topology = "ba"
+	// RMAT recursively picks adjacency matrix quadrants with the Graph500
+	// probabilities (0.57, 0.19, 0.19, 0.05), Nodes*Degree edges.
+	RMAT Topology = "rmat"
+	// Grid is a 2D grid with edges both ways between horizontal and
+	// vertical neighbours.
+	Grid Topology = "grid"
+	// Chain links node i to node i+1.
+	Chain Topology = "chain"
+)
+
+// Topologies lists every topology.
+var Topologies = []Topology{Uniform, ErdosRenyi, BarabasiAlbert, RMAT, Grid, Chain}
+
+// defaultDegree is used when Config.Degree is 0.
+var defaultDegree = map[Topology]int{
+	Uniform:        10,
+	ErdosRenyi:     5,
+	BarabasiAlbert: 3,
+	RMAT:           8,
+}
+
+// Config describes a graph.
+type Config struct {
+	Topology Topology
+	Nodes    int
+	Degree   int // meaning depends on the topology, 0 picks its default
+	Seed     uint64
+}
+
+func (c Config) String() string {
+	return fmt.Sprintf("%s n=%d degree=%d seed=%d", c.Topology, c.Nodes, c.degree(), c.Seed)
+}
+
+func (c Config) degree() int {
+	if c.Degree > 0 {
+		return c.Degree
+	}
+	return defaultDegree[c.Topology]
+}
+
+// ParseTopology validates a topology name.
+func ParseTopology(s string) (Topology, error) {
+	for _, t := range Topologies {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	names := make([]string, len(Topologies))
+	for i, t := range Topologies {
+		names[i] = string(t)
+	}
+	return "", fmt.Errorf("unknown topology %q, want one of %s", s, strings.Join(names, ", "))
+}
+
+// Generate builds the graph described by c.
+func Generate(c Config) (*graphdata.Edges, error) {
+	if c.Nodes < 1 || c.Nodes > math.MaxInt32 {
+		return nil, fmt.Errorf("nodes must be in 1..%d, got %d", math.MaxInt32, c.Nodes)
+	}
+	if c.Degree < 0 {
+		return nil, fmt.Errorf("negative degree %d", c.Degree)
+	}
+
+	// PCG is specified by math/rand/v2, its sequence does not change
+	// between Go releases.
+	r := rand.New(rand.NewPCG(c.Seed, 0x9e3779b97f4a7c15))
+	e := &graphdata.Edges{Nodes: c.Nodes}
+	n, d := c.Nodes, c.degree()
+
+	switch c.Topology {
+	case Uniform:
+		uniform(e, r, n, d)
+	case ErdosRenyi:
+		erdosRenyi(e, r, n, d)
+	case BarabasiAlbert:
+		barabasiAlbert(e, r, n, d)
+	case RMAT:
+		rmat(e, r, n, d)
+	case Grid:
+		grid(e, n)
+	case Chain:
+		for i := 0; i+1 < n; i++ {
+			e.Add(int32(i), int32(i+1))
+		}
+	default:
+		_, err := ParseTopology(string(c.Topology))
+		return nil, err
+	}
+	return e, nil
+}
+
+func uniform(e *graphdata.Edges, r *rand.Rand, n, maxDegree int) {
+	for i := 0; i < n; i++ {
+		connections := r.IntN(maxDegree) + 1
+		for j := 0; j < connections; j++ {
+			e.Add(int32(i), int32(r.IntN(n)))
+		}
+	}
+}
+
+func erdosRenyi(e *graphdata.Edges, r *rand.Rand, n, degree int) {
+	m := n * degree
+	e.Src, e.Dst = make([]int32, 0, m), make([]int32, 0, m)
+	for i := 0; i < m; i++ {
+		e.Add(int32(r.IntN(n)), int32(r.IntN(n)))
+	}
+}
+
+func barabasiAlbert(e *graphdata.Edges, r *rand.Rand, n, m int) {
+	// targets holds every edge endpoint so far, picking from it uniformly
+	// picks a node proportionally to its degree.
+	targets := make([]int32, 0, 2*n*m)
+
+	// Seed with a clique of the first m+1 nodes.
+	seed := min(n, m+1)
+	for i := 0; i < seed; i++ {
+		for j := i + 1; j < seed; j++ {
+			e.Add(int32(i), int32(j))
+			e.Add(int32(j), int32(i))
+			targets = append(targets, int32(i), int32(j))
+		}
+	}
+
+	picks := make([]int32, 0, m)
+	for i := seed; i < n; i++ {
+		picks = picks[:0]
+		for len(picks) < m {
+			if t := targets[r.IntN(len(targets))]; !slices.Contains(picks, t) {
+				picks = append(picks, t)
+			}
+		}
+		for _, t := range picks {
+			e.Add(int32(i), t)
+			e.Add(t, int32(i))
+			targets = append(targets, int32(i), t)
+		}
+	}
+}
+
+func rmat(e *graphdata.Edges, r *rand.Rand, n, degree int) {
+	const a, b, c = 0.57, 0.19, 0.19
+	levels := 0
+	for 1<<levels < n {
+		levels++
+	}
+
+	m := n * degree
+	e.Src, e.Dst = make([]int32, 0, m), make([]int32, 0, m)
+	for len(e.Src) < m {
+		var from, to int
+		for l := 0; l < levels; l++ {
+			p := r.Float64()
+			from, to = from<<1, to<<1
+			switch {
+			case p < a:
+			case p < a+b:
+				to |= 1
+			case p < a+b+c:
+				from |= 1
+			default:
+				from, to = from|1, to|1
+			}
+		}
+		// Draws outside a non power of two size are discarded.
+		if from < n && to < n {
+			e.Add(int32(from), int32(to))
+		}
+	}
+}
+
+func grid(e *graphdata.Edges, n int) {
+	side := int(math.Ceil(math.Sqrt(float64(n))))
+	for i := 0; i < n; i++ {
+		if right := i + 1; right%side != 0 && right < n {
+			e.Add(int32(i), int32(right))
+			e.Add(int32(right), int32(i))
+		}
+		if down := i + side; down < n {
+			e.Add(int32(i), int32(down))
+			e.Add(int32(down), int32(i))
+		}
+	}
+}
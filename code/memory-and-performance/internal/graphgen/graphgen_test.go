@@ -0,0 +1,74 @@
+package graphgen
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	for _, topo := range Topologies {
+		c := Config{Topology: topo, Nodes: 5000, Seed: 42}
+		a, err := Generate(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := Generate(c)
+		if !slices.Equal(a.Src, b.Src) || !slices.Equal(a.Dst, b.Dst) {
+			t.Errorf("%s: same seed gave different graphs", topo)
+		}
+		for i := range a.Src {
+			if a.Src[i] < 0 || int(a.Src[i]) >= c.Nodes || a.Dst[i] < 0 || int(a.Dst[i]) >= c.Nodes {
+				t.Fatalf("%s: edge %d -> %d out of range", topo, a.Src[i], a.Dst[i])
+			}
+		}
+
+		if topo == Grid || topo == Chain {
+			continue
+		}
+		c.Seed++
+		d, _ := Generate(c)
+		if slices.Equal(a.Dst, d.Dst) {
+			t.Errorf("%s: different seeds gave the same graph", topo)
+		}
+	}
+}
+
+func TestShapes(t *testing.T) {
+	gen := func(c Config) []int32 {
+		e, err := Generate(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.Degrees()
+	}
+
+	for i, d := range gen(Config{Topology: Uniform, Nodes: 1000, Seed: 1}) {
+		if d < 1 || d > 10 {
+			t.Fatalf("uniform: node %d has %d edges", i, d)
+		}
+	}
+
+	chain := gen(Config{Topology: Chain, Nodes: 10})
+	if chain[9] != 0 || chain[0] != 1 {
+		t.Errorf("chain degrees %v", chain)
+	}
+
+	// A 3x3 grid: corners have 2 neighbours, the centre 4.
+	grid := gen(Config{Topology: Grid, Nodes: 9})
+	if !slices.Equal(grid, []int32{2, 3, 2, 3, 4, 3, 2, 3, 2}) {
+		t.Errorf("grid degrees %v", grid)
+	}
+
+	// Preferential attachment gives hubs far above the mean degree of 2m.
+	ba := gen(Config{Topology: BarabasiAlbert, Nodes: 20_000, Degree: 3, Seed: 1})
+	if m := slices.Max(ba); m < 100 {
+		t.Errorf("ba: largest degree %d, expected a hub", m)
+	}
+
+	if _, err := Generate(Config{Topology: "tree", Nodes: 10}); err == nil {
+		t.Error("unknown topology accepted")
+	}
+	if _, err := Generate(Config{Topology: Uniform}); err == nil {
+		t.Error("empty graph accepted")
+	}
+}
@@ -0,0 +1,433 @@
+// Package harness is the shared lifecycle for every experiment in cmd/.
+//
+// A program registers the common flags, parses its own, and then goes through
+// setup (New) -> measured region (Measure) -> teardown (Close). Profiles and
+// other artifacts are written to a single output directory using the same
+// naming scheme: <exec>_<variant>_<goexperiment>_<kind>.<ext>. The execution
+// trace is <exec>_<variant>.trace; builds with a GOEXPERIMENT have their own
+// executable name, e.g. graphx.
+package harness
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/arl/statsviz"
+
+	"github.com/Elvis339/go_gc_eval/internal/results"
+	"github.com/Elvis339/go_gc_eval/internal/sampler"
+)
+
+// Options holds the flag values shared by all experiments.
+type Options struct {
+	Profile  bool          // enable profiling
+	Profiles string        // comma separated profile kinds, see profileKinds
+	OutDir   string        // where every artifact is written
+	Statsviz bool          // serve live runtime stats while the program runs
+	Hold     time.Duration // keep the process alive after the measured region
+
+	Metrics       time.Duration // runtime/metrics sampling interval, 0 disables it
+	MetricsFormat string        // csv or jsonl
+
+	Trace bool // write a runtime/trace execution trace of the measured region
+
+	Results string // results store every run is appended to, empty disables it
+}
+
+// MeasuredRegion is the runtime/trace region wrapping the measured region.
+const MeasuredRegion = "measured"
+
+// profileKinds maps a -profiles entry to the runtime/pprof profile it writes.
+// "cpu" is special cased since it is the only one that is started/stopped.
+var profileKinds = map[string]string{
+	"cpu":       "",
+	"mem":       "heap",
+	"allocs":    "allocs",
+	"block":     "block",
+	"mutex":     "mutex",
+	"goroutine": "goroutine",
+}
+
+// RegisterFlags adds the shared flags to fs and returns the destination struct.
+// Values are only valid after fs has been parsed.
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	fs.BoolVar(&o.Profile, "p", false, "Enable profiling (see -profiles)")
+	fs.StringVar(&o.Profiles, "profiles", "cpu,mem", "Comma separated profiles to write: cpu, mem, allocs, block, mutex, goroutine")
+	fs.StringVar(&o.OutDir, "out", "traces", "Output directory for profiles and other artifacts")
+	fs.BoolVar(&o.Statsviz, "statsviz", false, "Serve live runtime statistics with statsviz")
+	fs.DurationVar(&o.Hold, "hold", 0, "Keep the process alive after the run (0 with -statsviz waits for Ctrl+C)")
+	fs.DurationVar(&o.Metrics, "metrics", 0, "Record runtime/metrics at this interval during the run, e.g. 100ms (0 disables)")
+	fs.StringVar(&o.MetricsFormat, "metrics-format", "jsonl", "Format of the recorded metrics: csv or jsonl")
+	fs.BoolVar(&o.Trace, "trace", false, "Write a runtime/trace execution trace of the run to <out>/<exec>_<variant>.trace")
+	fs.StringVar(&o.Results, "results", results.DefaultPath, "Append a record of the run to this JSONL results store (empty disables)")
+	return o
+}
+
+// ExperimentArgs returns args without the flags registered by RegisterFlags.
+// What is left selects the experiment itself: runs that only differ in where
+// their artifacts go or what is recorded, e.g. the -out directory the matrix
+// gives every repetition, measure the same thing.
+func ExperimentArgs(args []string) []string {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	RegisterFlags(fs)
+
+	var out []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			out = append(out, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			out = append(out, arg)
+			continue
+		}
+		// A non-boolean flag without "=value" takes the next argument.
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) {
+			i++
+		}
+	}
+	return out
+}
+
+// Harness drives a single experiment run.
+type Harness struct {
+	opts      *Options
+	name      string
+	variant   string
+	kinds     []string
+	elapsed   time.Duration
+	generated []string
+
+	measured bool
+	usage    usage // runtime and rusage counters consumed by the measured region
+}
+
+// New validates the options and performs the setup phase: it creates the
+// output directory and starts statsviz when requested.
+func New(opts *Options, variant string) (*Harness, error) {
+	kinds, err := parseKinds(opts.Profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
+		opts:    opts,
+		name:    baseName(ExecutableName(), variant, Experiment()),
+		variant: variant,
+		kinds:   kinds,
+	}
+
+	if err := os.MkdirAll(opts.OutDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	if opts.MetricsFormat != "csv" && opts.MetricsFormat != "jsonl" {
+		return nil, fmt.Errorf("unknown metrics format %q", opts.MetricsFormat)
+	}
+
+	if opts.Statsviz {
+		url, err := startStatsvizServer()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("statsviz server: %s\n", url)
+	}
+
+	return h, nil
+}
+
+// Name returns the base name used for every artifact of this run.
+func (h *Harness) Name() string {
+	return h.name
+}
+
+// Path returns the output path for an artifact with the given suffix,
+// e.g. Path("cpu.pprof") -> traces/graph_compact_std_cpu.pprof.
+func (h *Harness) Path(suffix string) string {
+	return filepath.Join(h.opts.OutDir, h.name+"_"+suffix)
+}
+
+// Measure runs fn as the measured region and returns its wall time. CPU
+// profiling, the sampling rates of block and mutex profiles, the metrics
+// sampler and the execution trace only cover fn. In the trace, fn runs inside
+// a region named MeasuredRegion.
+func (h *Harness) Measure(fn func()) (time.Duration, error) {
+	stop, err := h.startProfiling()
+	if err != nil {
+		return 0, err
+	}
+
+	before := readUsage()
+	start := time.Now()
+	trace.WithRegion(context.Background(), MeasuredRegion, fn)
+	h.elapsed = time.Since(start)
+	h.usage = readUsage().sub(before)
+	h.measured = true
+
+	stop()
+	return h.elapsed, nil
+}
+
+// Close is the teardown phase: it writes the snapshot profiles, appends the
+// run to the results store, lists every generated artifact and holds the
+// process open if requested.
+func (h *Harness) Close() error {
+	err := h.writeProfiles()
+	if rerr := h.appendResult(); rerr != nil && err == nil {
+		err = rerr
+	}
+	if len(h.generated) > 0 {
+		fmt.Printf("Generated: %s\n", strings.Join(h.generated, ", "))
+	}
+	h.hold()
+	return err
+}
+
+func (h *Harness) enabled(kind string) bool {
+	if !h.opts.Profile {
+		return false
+	}
+	for _, k := range h.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Harness) startProfiling() (func(), error) {
+	var stops []func()
+
+	if h.enabled("block") {
+		runtime.SetBlockProfileRate(1)
+		stops = append(stops, func() { runtime.SetBlockProfileRate(0) })
+	}
+	if h.enabled("mutex") {
+		prev := runtime.SetMutexProfileFraction(1)
+		stops = append(stops, func() { runtime.SetMutexProfileFraction(prev) })
+	}
+
+	stopAll := func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+
+	if h.enabled("cpu") {
+		path := h.Path("cpu.pprof")
+		cpuFile, err := os.Create(path)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("create CPU profile file: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			stopAll()
+			return nil, fmt.Errorf("start CPU profiling: %w", err)
+		}
+		h.generated = append(h.generated, path)
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		})
+	}
+
+	if h.opts.Trace {
+		path := filepath.Join(h.opts.OutDir, baseName(ExecutableName(), h.variant, "")+".trace")
+		traceFile, err := os.Create(path)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("create trace file: %w", err)
+		}
+		if err := trace.Start(traceFile); err != nil {
+			traceFile.Close()
+			stopAll()
+			return nil, fmt.Errorf("start tracing: %w", err)
+		}
+		h.generated = append(h.generated, path)
+		stops = append(stops, func() {
+			trace.Stop()
+			traceFile.Close()
+		})
+	}
+
+	if h.opts.Metrics > 0 {
+		path := h.Path("metrics." + h.opts.MetricsFormat)
+		w, err := sampler.Create(path)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("create metrics file: %w", err)
+		}
+		s := sampler.New(h.opts.Metrics, w)
+		s.Start()
+		h.generated = append(h.generated, path)
+		stops = append(stops, func() {
+			if err := s.Stop(); err != nil {
+				log.Printf("Failed to record metrics: %v", err)
+			}
+		})
+	}
+
+	return stopAll, nil
+}
+
+func (h *Harness) writeProfiles() error {
+	if !h.opts.Profile {
+		return nil
+	}
+
+	// Snapshot profiles should reflect live data, not garbage left from the run.
+	runtime.GC()
+	for _, kind := range h.kinds {
+		if kind == "cpu" {
+			continue
+		}
+		path := h.Path(kind + ".pprof")
+		if err := writeProfile(profileKinds[kind], path); err != nil {
+			return err
+		}
+		h.generated = append(h.generated, path)
+	}
+	return nil
+}
+
+func writeProfile(name, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s profile file: %w", name, err)
+	}
+	defer f.Close()
+
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		return fmt.Errorf("write %s profile: %w", name, err)
+	}
+	return nil
+}
+
+func (h *Harness) hold() {
+	d := h.opts.Hold
+	if d <= 0 && !h.opts.Statsviz {
+		return
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	if d <= 0 {
+		fmt.Println("Press Ctrl+C to stop the server")
+		<-stop
+		return
+	}
+
+	fmt.Printf("Holding for %s (Ctrl+C to stop)\n", d)
+	select {
+	case <-stop:
+	case <-time.After(d):
+	}
+}
+
+func parseKinds(s string) ([]string, error) {
+	var kinds []string
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if _, ok := profileKinds[k]; !ok {
+			return nil, fmt.Errorf("unknown profile kind %q", k)
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds, nil
+}
+
+func startStatsvizServer() (string, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return "", fmt.Errorf("listen for statsviz: %w", err)
+	}
+	p, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		listener.Close()
+		return "", errors.New("failed to find available port")
+	}
+
+	if err := statsviz.Register(http.DefaultServeMux); err != nil {
+		listener.Close()
+		return "", fmt.Errorf("register statsviz: %w", err)
+	}
+
+	go func() {
+		// The server lives until the process exits.
+		_ = http.Serve(listener, nil)
+	}()
+
+	return fmt.Sprintf("http://localhost:%d/debug/statsviz/", p.Port), nil
+}
+
+// ExecutableName returns the base name of the running binary, e.g. "graphx".
+func ExecutableName() string {
+	executable, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	return filepath.Base(executable)
+}
+
+// Experiment returns the GOEXPERIMENT the binary was built with, or "std"
+// when it was built without one.
+func Experiment() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "std"
+	}
+	for _, s := range info.Settings {
+		if s.Key == "GOEXPERIMENT" && s.Value != "" {
+			return s.Value
+		}
+	}
+	return "std"
+}
+
+func baseName(execName, variant, experiment string) string {
+	parts := []string{execName}
+	if variant != "" {
+		parts = append(parts, variant)
+	}
+	if experiment != "" {
+		parts = append(parts, experiment)
+	}
+	return sanitize(strings.Join(parts, "_"))
+}
+
+// sanitize keeps file names portable, GOEXPERIMENT may contain commas.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		}
+		return '-'
+	}, s)
+}
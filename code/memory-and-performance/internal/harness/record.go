@@ -0,0 +1,98 @@
+package harness
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/results"
+)
+
+// recordedEnv are the variables that change GC behaviour and are stored with
+// every result when set.
+var recordedEnv = []string{"GOGC", "GOMEMLIMIT", "GOMAXPROCS", "GODEBUG"}
+
+// usage is a snapshot of the counters a run is charged for.
+type usage struct {
+	gcCycles    uint64
+	gcCPU       float64 // seconds
+	heapAllocs  uint64  // bytes
+	gcPause     time.Duration
+	minorFaults int64
+	majorFaults int64
+}
+
+var usageMetrics = []metrics.Sample{
+	{Name: "/gc/cycles/total:gc-cycles"},
+	{Name: "/cpu/classes/gc/total:cpu-seconds"},
+	{Name: "/gc/heap/allocs:bytes"},
+}
+
+func readUsage() usage {
+	samples := append([]metrics.Sample(nil), usageMetrics...)
+	metrics.Read(samples)
+
+	var gcStats debug.GCStats
+	debug.ReadGCStats(&gcStats)
+
+	u := usage{
+		gcCycles:   samples[0].Value.Uint64(),
+		gcCPU:      samples[1].Value.Float64(),
+		heapAllocs: samples[2].Value.Uint64(),
+		gcPause:    gcStats.PauseTotal,
+	}
+	u.minorFaults, u.majorFaults = pageFaults()
+	return u
+}
+
+func (u usage) sub(v usage) usage {
+	return usage{
+		gcCycles:    u.gcCycles - v.gcCycles,
+		gcCPU:       u.gcCPU - v.gcCPU,
+		heapAllocs:  u.heapAllocs - v.heapAllocs,
+		gcPause:     u.gcPause - v.gcPause,
+		minorFaults: u.minorFaults - v.minorFaults,
+		majorFaults: u.majorFaults - v.majorFaults,
+	}
+}
+
+// appendResult stores the measured region in the results store. Runs that
+// never reached Measure are not recorded.
+func (h *Harness) appendResult() error {
+	if h.opts.Results == "" || !h.measured {
+		return nil
+	}
+
+	r := &results.Record{
+		Time:        time.Now(),
+		Experiment:  ExecutableName(),
+		Variant:     h.variant,
+		Args:        os.Args[1:],
+		Wall:        h.elapsed,
+		GCCycles:    h.usage.gcCycles,
+		GCPause:     h.usage.gcPause,
+		GCCPU:       time.Duration(h.usage.gcCPU * float64(time.Second)),
+		HeapAllocs:  h.usage.heapAllocs,
+		MinorFaults: h.usage.minorFaults,
+		MajorFaults: h.usage.majorFaults,
+		Fingerprint: results.Collect(),
+	}
+	for _, k := range recordedEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			if r.Env == nil {
+				r.Env = make(map[string]string)
+			}
+			r.Env[k] = v
+		}
+	}
+
+	// CPU time and peak RSS are only available for the whole process.
+	r.User, r.System, r.MaxRSS = processUsage()
+
+	if err := results.Append(h.opts.Results, r); err != nil {
+		return fmt.Errorf("append result: %w", err)
+	}
+	return nil
+}
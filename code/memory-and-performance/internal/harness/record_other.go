@@ -0,0 +1,12 @@
+//go:build !unix
+
+package harness
+
+import "time"
+
+// pageFaults is not supported on this platform, results record zero faults.
+func pageFaults() (minor, major int64) { return 0, 0 }
+
+// processUsage is not supported on this platform, results record zero CPU
+// time and peak RSS.
+func processUsage() (user, system time.Duration, maxRSS int64) { return 0, 0, 0 }
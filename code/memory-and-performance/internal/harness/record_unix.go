@@ -0,0 +1,32 @@
+//go:build unix
+
+package harness
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// pageFaults returns the minor and major page faults of the process so far.
+func pageFaults() (minor, major int64) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, 0
+	}
+	return int64(ru.Minflt), int64(ru.Majflt)
+}
+
+// processUsage returns the CPU time and the peak RSS in bytes of the process.
+func processUsage() (user, system time.Duration, maxRSS int64) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, 0, 0
+	}
+	maxRSS = int64(ru.Maxrss) * 1024
+	// ru_maxrss is in bytes on macOS and in kilobytes everywhere else.
+	if runtime.GOOS == "darwin" {
+		maxRSS = int64(ru.Maxrss)
+	}
+	return time.Duration(ru.Utime.Nano()), time.Duration(ru.Stime.Nano()), maxRSS
+}
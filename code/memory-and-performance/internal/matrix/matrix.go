@@ -0,0 +1,195 @@
+// Package matrix expands a declarative experiment file into the cartesian
+// product of binaries, builds, argument sets and environment values, and runs
+// every cell in fresh processes with its outputs kept in a per-cell directory.
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written as a string in experiment files, e.g. "5m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Spec is an experiment file.
+//
+//	{
+//	  "name": "graph-layouts",
+//	  "binaries": ["graph"],
+//	  "builds": ["", "x"],
+//	  "args": [["-v", "ptr-chasing", "-s", "2000000"], ["-v", "compact", "-s", "2000000"]],
+//	  "env": {"GOGC": ["100", "400"], "GOMAXPROCS": ["1", "8"]},
+//	  "repetitions": 3,
+//	  "timeout": "5m",
+//	  "metrics": "50ms"
+//	}
+type Spec struct {
+	Name   string `json:"name"`
+	Out    string `json:"out,omitempty"`     // results root, default traces/matrix
+	BinDir string `json:"bin_dir,omitempty"` // default bin
+
+	// Binaries are names in BinDir. Builds are suffixes appended to every
+	// binary, "x" selects the Green Tea build made by the Makefile.
+	Binaries []string `json:"binaries"`
+	Builds   []string `json:"builds,omitempty"`
+
+	// Args are alternative argument sets, Env maps a variable to the values
+	// it takes. An empty value leaves the variable as inherited; GODEBUG
+	// values are merged with gctrace=1.
+	Args [][]string          `json:"args,omitempty"`
+	Env  map[string][]string `json:"env,omitempty"`
+
+	Repetitions int      `json:"repetitions,omitempty"` // default 1
+	Timeout     Duration `json:"timeout,omitempty"`     // per run, 0 means none
+
+	// Harness flags passed to every run, see internal/harness.
+	Profile bool     `json:"profile,omitempty"`
+	Metrics Duration `json:"metrics,omitempty"`
+	Trace   bool     `json:"trace,omitempty"`
+}
+
+// Load reads and validates an experiment file. Only JSON is accepted.
+func Load(path string) (*Spec, error) {
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		return nil, fmt.Errorf("%s: YAML is not supported, write the experiment as JSON", path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Spec
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+func (s *Spec) validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(s.Binaries) == 0 {
+		return errors.New("at least one binary is required")
+	}
+	if s.Out == "" {
+		s.Out = filepath.Join("traces", "matrix")
+	}
+	if s.BinDir == "" {
+		s.BinDir = "bin"
+	}
+	if len(s.Builds) == 0 {
+		s.Builds = []string{""}
+	}
+	if len(s.Args) == 0 {
+		s.Args = [][]string{nil}
+	}
+	if s.Repetitions <= 0 {
+		s.Repetitions = 1
+	}
+	for k, vs := range s.Env {
+		if len(vs) == 0 {
+			return fmt.Errorf("env %s has no values", k)
+		}
+	}
+	return nil
+}
+
+// Cell is one point of the matrix.
+type Cell struct {
+	ID     string   `json:"id"`
+	Binary string   `json:"binary"`
+	Args   []string `json:"args"`
+	Env    []string `json:"env"` // KEY=VALUE, empty values are left out
+}
+
+// Cells expands the spec in a stable order: binaries, builds, argument sets,
+// then environment variables sorted by name.
+func (s *Spec) Cells() []Cell {
+	keys := make([]string, 0, len(s.Env))
+	for k := range s.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// envs is the product of every variable's values.
+	envs := [][]string{nil}
+	for _, k := range keys {
+		var next [][]string
+		for _, prefix := range envs {
+			for _, v := range s.Env[k] {
+				env := append(append([]string(nil), prefix...), k+"="+v)
+				next = append(next, env)
+			}
+		}
+		envs = next
+	}
+
+	var cells []Cell
+	for _, bin := range s.Binaries {
+		for _, build := range s.Builds {
+			for _, args := range s.Args {
+				for _, env := range envs {
+					c := Cell{
+						Binary: filepath.Join(s.BinDir, bin+build),
+						Args:   append([]string(nil), args...),
+					}
+					for _, kv := range env {
+						if !strings.HasSuffix(kv, "=") {
+							c.Env = append(c.Env, kv)
+						}
+					}
+					c.ID = cellID(len(cells), bin+build, c.Args, c.Env)
+					cells = append(cells, c)
+				}
+			}
+		}
+	}
+	return cells
+}
+
+func cellID(i int, bin string, args, env []string) string {
+	parts := append([]string{fmt.Sprintf("%03d", i), bin}, args...)
+	parts = append(parts, env...)
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.':
+			return r
+		}
+		return '-'
+	}, strings.Join(parts, "_"))
+	for strings.Contains(id, "--") {
+		id = strings.ReplaceAll(id, "--", "-")
+	}
+	if len(id) > 120 {
+		id = id[:120]
+	}
+	return strings.Trim(id, "-")
+}
@@ -0,0 +1,112 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCells(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "e.json")
+	err := os.WriteFile(path, []byte(`{
+		"name": "t",
+		"binaries": ["graph", "btree"],
+		"builds": ["", "x"],
+		"args": [["-v", "compact"], ["-v", "ptr-chasing"]],
+		"env": {"GOGC": ["100", "off"], "GOMEMLIMIT": ["", "1GiB"]}
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cells := s.Cells()
+	if len(cells) != 2*2*2*2*2 {
+		t.Fatalf("got %d cells, want 32", len(cells))
+	}
+	first := cells[0]
+	if first.Binary != filepath.Join("bin", "graph") || !slices.Equal(first.Args, []string{"-v", "compact"}) {
+		t.Errorf("first cell %+v", first)
+	}
+	if !slices.Equal(first.Env, []string{"GOGC=100"}) {
+		t.Errorf("empty value not dropped: %v", first.Env)
+	}
+	if got := cells[1].Env; !slices.Equal(got, []string{"GOGC=100", "GOMEMLIMIT=1GiB"}) {
+		t.Errorf("second cell env %v", got)
+	}
+	if cells[8].Binary != filepath.Join("bin", "graphx") {
+		t.Errorf("build suffix not applied: %s", cells[8].Binary)
+	}
+
+	ids := make(map[string]bool)
+	for _, c := range cells {
+		if ids[c.ID] {
+			t.Errorf("duplicate id %s", c.ID)
+		}
+		ids[c.ID] = true
+	}
+	if s.Repetitions != 1 || s.Out == "" {
+		t.Errorf("defaults not applied: %+v", s)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "e.json")
+	if err := os.WriteFile(path, []byte(`{"name": "t", "binaries": ["graph"], "repetition": 3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("misspelled field accepted")
+	}
+}
+
+func TestLoadRejectsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "e.yaml")
+	if err := os.WriteFile(path, []byte("name: t\nbinaries: [graph]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "JSON") {
+		t.Errorf("YAML file: got error %v, want one asking for JSON", err)
+	}
+}
+
+func TestRunRecordsMissingBinary(t *testing.T) {
+	dir := t.TempDir()
+	spec := &Spec{
+		Name: "t", Out: dir, BinDir: dir, Repetitions: 1,
+		Binaries: []string{"missing"}, Builds: []string{""}, Args: [][]string{nil},
+	}
+
+	m, err := Run(context.Background(), spec, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Cells) != 1 || len(m.Cells[0].Reps) != 1 {
+		t.Fatalf("cells %+v", m.Cells)
+	}
+	if r := m.Cells[0].Reps[0]; r.ExitCode != -1 || r.Error == "" {
+		t.Errorf("missing binary recorded as exit code %d, error %q", r.ExitCode, r.Error)
+	}
+	if m.Finished == nil {
+		t.Error("finished run has no finish time")
+	}
+}
+
+func TestUnfinishedManifestOmitsFinished(t *testing.T) {
+	b, err := json.Marshal(&Manifest{Started: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "finished") {
+		t.Errorf("unfinished manifest has a finish time: %s", b)
+	}
+}
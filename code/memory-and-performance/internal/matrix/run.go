@@ -0,0 +1,165 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/runner"
+)
+
+// Rep is one repetition of a cell.
+type Rep struct {
+	Dir      string        `json:"dir"`
+	Wall     time.Duration `json:"wall_ns"`
+	User     time.Duration `json:"user_ns"`
+	System   time.Duration `json:"system_ns"`
+	MaxRSS   int64         `json:"max_rss_bytes"`
+	ExitCode int           `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+
+	GCCycles   int           `json:"gc_cycles"`
+	GCSTW      time.Duration `json:"gc_stw_ns"`
+	GCCPU      time.Duration `json:"gc_cpu_ns"`
+	GCPercent  int           `json:"gc_percent"` // final cumulative GC CPU share from gctrace
+	PeakHeapMB float64       `json:"peak_heap_mb"`
+
+	Files []string `json:"files"` // relative to Dir
+}
+
+// CellResult is a cell with the outcome of its repetitions.
+type CellResult struct {
+	Cell
+	Dir  string `json:"dir"`
+	Reps []Rep  `json:"reps"`
+}
+
+// Manifest describes a matrix run. It is written to manifest.json in the run
+// directory after every cell, so an interrupted run keeps what finished.
+type Manifest struct {
+	Spec     Spec         `json:"spec"`
+	Dir      string       `json:"dir"`
+	Started  time.Time    `json:"started"`
+	Finished *time.Time   `json:"finished,omitempty"` // nil until every cell ran
+	Cells    []CellResult `json:"cells"`
+}
+
+// Run executes every cell of spec, repetitions of a cell back to back, and
+// stores the outputs under <out>/<name>/<cell id>/<rep>. Progress lines go to
+// log. A failing repetition is recorded in the manifest and does not stop
+// the run; Run only fails when outputs cannot be written or ctx is done.
+func Run(ctx context.Context, spec *Spec, log io.Writer) (*Manifest, error) {
+	m := &Manifest{
+		Spec:    *spec,
+		Dir:     filepath.Join(spec.Out, spec.Name),
+		Started: time.Now(),
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	cells := spec.Cells()
+	for i, c := range cells {
+		cr := CellResult{Cell: c, Dir: filepath.Join(m.Dir, c.ID)}
+		for rep := 1; rep <= spec.Repetitions; rep++ {
+			if err := ctx.Err(); err != nil {
+				return m, err
+			}
+			r, err := runRep(ctx, spec, c, filepath.Join(cr.Dir, fmt.Sprintf("rep%d", rep)))
+			if err != nil {
+				return m, err
+			}
+			status := "ok"
+			if r.Error != "" {
+				status = r.Error
+			}
+			fmt.Fprintf(log, "[%d/%d] %s rep %d: %s, %s\n", i+1, len(cells), c.ID, rep, r.Wall.Round(time.Millisecond), status)
+			cr.Reps = append(cr.Reps, r)
+		}
+		m.Cells = append(m.Cells, cr)
+		if err := m.write(); err != nil {
+			return m, err
+		}
+	}
+
+	finished := time.Now()
+	m.Finished = &finished
+	return m, m.write()
+}
+
+func runRep(ctx context.Context, spec *Spec, c Cell, dir string) (Rep, error) {
+	rep := Rep{Dir: dir}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return rep, err
+	}
+	stdout, err := os.Create(filepath.Join(dir, "stdout.txt"))
+	if err != nil {
+		return rep, err
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(dir, "gctrace.txt"))
+	if err != nil {
+		return rep, err
+	}
+	defer stderr.Close()
+
+	// Harness flags go first: binarytrees takes a positional argument.
+	args := []string{"-out", dir}
+	if spec.Profile {
+		args = append(args, "-p")
+	}
+	if spec.Metrics > 0 {
+		args = append(args, "-metrics", time.Duration(spec.Metrics).String())
+	}
+	if spec.Trace {
+		args = append(args, "-trace")
+	}
+	args = append(args, c.Args...)
+
+	res, err := runner.Run(ctx, runner.Spec{
+		Path:    c.Binary,
+		Args:    args,
+		Env:     c.Env,
+		Timeout: time.Duration(spec.Timeout),
+		GCTrace: true,
+		Stdout:  stdout,
+		Stderr:  stderr,
+	})
+	// A process that never started, e.g. a missing binary, is recorded with
+	// exit code -1 and its error like any other failed repetition.
+	if err != nil {
+		if ctx.Err() != nil {
+			return rep, ctx.Err()
+		}
+		rep.Error = err.Error()
+	}
+
+	rep.Wall, rep.User, rep.System = res.Wall, res.User, res.System
+	rep.MaxRSS, rep.ExitCode = res.MaxRSS, res.ExitCode
+	s := res.GCSummary()
+	rep.GCCycles, rep.GCSTW, rep.GCCPU = s.Cycles, s.TotalSTW, s.TotalCPU
+	rep.GCPercent, rep.PeakHeapMB = s.FinalGCPercent, s.PeakHeap
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return rep, err
+	}
+	for _, e := range entries {
+		rep.Files = append(rep.Files, e.Name())
+	}
+	sort.Strings(rep.Files)
+	return rep, nil
+}
+
+func (m *Manifest) write() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.Dir, "manifest.json"), append(b, '\n'), 0o644)
+}
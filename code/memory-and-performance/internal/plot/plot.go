@@ -0,0 +1,305 @@
+// Package plot renders small self-contained SVG charts: no scripts, no
+// external fonts or stylesheets, so the output can be embedded in a static
+// HTML page or committed next to a blog post.
+package plot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+// Palette is used for series in order.
+var Palette = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 360
+	marginLeft    = 70
+	marginRight   = 20
+	marginTop     = 40
+	marginBottom  = 50
+)
+
+// Series is one line of a LineChart.
+type Series struct {
+	Name   string
+	X, Y   []float64
+	Color  string // defaults to Palette[i]
+	Dashed bool
+	Points bool     // draw a dot for every point
+	Labels []string // optional tooltip per point
+
+	Scatter  bool // dots only, no connecting line
+	Annotate bool // print Labels next to the dots
+}
+
+// Marker is a vertical tick along the x axis, e.g. a GC cycle.
+type Marker struct {
+	X      float64
+	Series int    // Palette index, usually the run the marker belongs to
+	Label  string // tooltip
+}
+
+// LineChart plots one or more series on shared axes.
+type LineChart struct {
+	Title          string
+	XLabel, YLabel string
+	Width, Height  int
+	Series         []Series
+	Markers        []Marker
+}
+
+// SVG writes the chart as a standalone <svg> element.
+func (c *LineChart) SVG(w io.Writer) error {
+	width, height := size(c.Width, c.Height)
+
+	xmin, xmax, ymin, ymax := math.Inf(1), math.Inf(-1), 0.0, math.Inf(-1)
+	for _, s := range c.Series {
+		for i := range s.X {
+			xmin, xmax = math.Min(xmin, s.X[i]), math.Max(xmax, s.X[i])
+			ymin, ymax = math.Min(ymin, s.Y[i]), math.Max(ymax, s.Y[i])
+		}
+	}
+	if math.IsInf(xmin, 1) {
+		xmin, xmax, ymax = 0, 1, 1
+	}
+	xticks := Ticks(xmin, xmax, 8)
+	yticks := Ticks(ymin, ymax, 6)
+	xmin, xmax = math.Min(xmin, xticks[0]), math.Max(xmax, xticks[len(xticks)-1])
+	ymin, ymax = yticks[0], yticks[len(yticks)-1]
+
+	px := scale(xmin, xmax, marginLeft, float64(width-marginRight))
+	py := scale(ymin, ymax, float64(height-marginBottom), marginTop)
+
+	var b strings.Builder
+	header(&b, width, height, c.Title)
+	axes(&b, width, height, c.XLabel, c.YLabel)
+	for _, t := range xticks {
+		x := px(t)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#ddd"/>`, x, marginTop, x, height-marginBottom)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x, height-marginBottom+16, Format(t))
+	}
+	for _, t := range yticks {
+		y := py(t)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, marginLeft, y, width-marginRight, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, marginLeft-6, y+4, Format(t))
+	}
+
+	for _, m := range c.Markers {
+		x := px(m.X)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="%s" stroke-opacity="0.5" stroke-width="2"><title>%s</title></line>`,
+			x, height-marginBottom, x, height-marginBottom-10-6*(m.Series%3), color(m.Series), html.EscapeString(m.Label))
+	}
+
+	for i, s := range c.Series {
+		if len(s.X) == 0 {
+			continue
+		}
+		stroke := color(i)
+		if s.Color != "" {
+			stroke = s.Color
+		}
+		var pts []string
+		for j := range s.X {
+			pts = append(pts, fmt.Sprintf("%.1f,%.1f", px(s.X[j]), py(s.Y[j])))
+		}
+		dash := ""
+		if s.Dashed {
+			dash = ` stroke-dasharray="6 4"`
+		}
+		if !s.Scatter {
+			fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5"%s points="%s"/>`, stroke, dash, strings.Join(pts, " "))
+		}
+		if s.Points || s.Scatter {
+			for j := range s.X {
+				label := fmt.Sprintf("%s: %s, %s", s.Name, Format(s.X[j]), Format(s.Y[j]))
+				if j < len(s.Labels) {
+					label = s.Labels[j]
+				}
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s</title></circle>`,
+					px(s.X[j]), py(s.Y[j]), stroke, html.EscapeString(label))
+				if s.Annotate && j < len(s.Labels) {
+					fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="10" fill="%s">%s</text>`,
+						px(s.X[j])+5, py(s.Y[j])-5, stroke, html.EscapeString(s.Labels[j]))
+				}
+			}
+		}
+	}
+
+	var names, colors []string
+	for i, s := range c.Series {
+		if s.Dashed {
+			continue // dashed companions share the name of their solid series
+		}
+		names = append(names, s.Name)
+		if s.Color != "" {
+			colors = append(colors, s.Color)
+		} else {
+			colors = append(colors, color(i))
+		}
+	}
+	legend(&b, width, names, colors)
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// BarSeries is one group member of a BarChart.
+type BarSeries struct {
+	Name   string
+	Values []float64 // one value per category
+}
+
+// BarChart draws grouped bars, one group per category.
+type BarChart struct {
+	Title          string
+	XLabel, YLabel string
+	Width, Height  int
+	Categories     []string
+	Series         []BarSeries
+}
+
+// SVG writes the chart as a standalone <svg> element.
+func (c *BarChart) SVG(w io.Writer) error {
+	width, height := size(c.Width, c.Height)
+
+	ymax := 0.0
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			ymax = math.Max(ymax, v)
+		}
+	}
+	if ymax == 0 {
+		ymax = 1
+	}
+	yticks := Ticks(0, ymax, 6)
+	py := scale(0, yticks[len(yticks)-1], float64(height-marginBottom), marginTop)
+
+	var b strings.Builder
+	header(&b, width, height, c.Title)
+	axes(&b, width, height, c.XLabel, c.YLabel)
+	for _, t := range yticks {
+		y := py(t)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, marginLeft, y, width-marginRight, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, marginLeft-6, y+4, Format(t))
+	}
+
+	n := len(c.Categories)
+	if n > 0 && len(c.Series) > 0 {
+		group := float64(width-marginLeft-marginRight) / float64(n)
+		bar := group * 0.8 / float64(len(c.Series))
+		every := int(math.Ceil(float64(n) / 12)) // keep category labels readable
+		for i, cat := range c.Categories {
+			x0 := float64(marginLeft) + float64(i)*group + group*0.1
+			for j, s := range c.Series {
+				if i >= len(s.Values) {
+					continue
+				}
+				y := py(s.Values[i])
+				fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %s</title></rect>`,
+					x0+float64(j)*bar, y, bar, float64(height-marginBottom)-y, color(j),
+					html.EscapeString(s.Name), html.EscapeString(cat), Format(s.Values[i]))
+			}
+			if i%every == 0 {
+				fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+					x0+group*0.4, height-marginBottom+16, html.EscapeString(cat))
+			}
+		}
+	}
+
+	names := make([]string, len(c.Series))
+	colors := make([]string, len(c.Series))
+	for i, s := range c.Series {
+		names[i], colors[i] = s.Name, color(i)
+	}
+	legend(&b, width, names, colors)
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func size(w, h int) (int, int) {
+	if w == 0 {
+		w = defaultWidth
+	}
+	if h == 0 {
+		h = defaultHeight
+	}
+	return w, h
+}
+
+func color(i int) string {
+	return Palette[i%len(Palette)]
+}
+
+func scale(d0, d1, r0, r1 float64) func(float64) float64 {
+	if d1 == d0 {
+		d1 = d0 + 1
+	}
+	return func(v float64) float64 {
+		return r0 + (v-d0)/(d1-d0)*(r1-r0)
+	}
+}
+
+func header(b *strings.Builder, width, height int, title string) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		width, height, width, height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="white"/>`)
+	fmt.Fprintf(b, `<text x="%d" y="20" font-size="14" font-weight="bold">%s</text>`, marginLeft, html.EscapeString(title))
+}
+
+func axes(b *strings.Builder, width, height int, xlabel, ylabel string) {
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`, marginLeft, height-marginBottom, width-marginRight, height-marginBottom)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`, marginLeft, marginTop, marginLeft, height-marginBottom)
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`, (marginLeft+width-marginRight)/2, height-12, html.EscapeString(xlabel))
+	fmt.Fprintf(b, `<text transform="translate(16 %d) rotate(-90)" text-anchor="middle">%s</text>`, (marginTop+height-marginBottom)/2, html.EscapeString(ylabel))
+}
+
+func legend(b *strings.Builder, width int, names, colors []string) {
+	x := width - marginRight
+	for i := len(names) - 1; i >= 0; i-- {
+		x -= 14 + 7*len(names[i]) + 16
+		fmt.Fprintf(b, `<rect x="%d" y="12" width="12" height="12" fill="%s"/>`, x, colors[i])
+		fmt.Fprintf(b, `<text x="%d" y="22">%s</text>`, x+16, html.EscapeString(names[i]))
+	}
+}
+
+// Ticks returns about n evenly spaced round values covering [lo, hi].
+func Ticks(lo, hi float64, n int) []float64 {
+	if hi <= lo {
+		hi = lo + 1
+	}
+	step := niceStep((hi - lo) / float64(n))
+	start := math.Floor(lo/step) * step
+	var ticks []float64
+	for v := start; v <= hi+step*1e-9; v += step {
+		ticks = append(ticks, math.Round(v/step)*step)
+	}
+	if ticks[len(ticks)-1] < hi {
+		ticks = append(ticks, ticks[len(ticks)-1]+step)
+	}
+	return ticks
+}
+
+func niceStep(raw float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch f := raw / exp; {
+	case f <= 1:
+		return exp
+	case f <= 2:
+		return 2 * exp
+	case f <= 5:
+		return 5 * exp
+	}
+	return 10 * exp
+}
+
+// Format prints v compactly for axis labels and tooltips.
+func Format(v float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.4g", v), ".0")
+}
@@ -0,0 +1,128 @@
+package results
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects records. It is built from key=value terms, every term has to
+// match:
+//
+//	exp=graphx          experiment (executable) name
+//	variant=compact     variant
+//	build=greenteagc    GOEXPERIMENT, "std" without one
+//	go=go1.26.0         Go version
+//	rev=85c1c22         VCS revision prefix
+//	host=lab1           hostname
+//	cpu=EPYC            substring of the CPU model
+//	procs=8             GOMAXPROCS
+//	args=-s 2000000     substring of the space joined arguments
+//	env.GOGC=200        environment variable, empty for unset
+//	since=24h           newer than a duration ago, a date or an RFC 3339 time
+//	until=2026-10-01    older than
+type Filter struct {
+	terms []term
+}
+
+type term struct {
+	key, value string
+	match      func(r *Record) bool
+}
+
+// ParseFilter parses terms like "exp=graph" "build=std".
+func ParseFilter(terms ...string) (Filter, error) {
+	var f Filter
+	for _, t := range terms {
+		k, v, ok := strings.Cut(t, "=")
+		if !ok {
+			return f, fmt.Errorf("filter %q: want key=value", t)
+		}
+		m, err := matcher(k, v)
+		if err != nil {
+			return f, fmt.Errorf("filter %q: %w", t, err)
+		}
+		f.terms = append(f.terms, term{k, v, m})
+	}
+	return f, nil
+}
+
+// Match reports whether r satisfies every term.
+func (f Filter) Match(r *Record) bool {
+	for _, t := range f.terms {
+		if !t.match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the records matching f.
+func (f Filter) Apply(records []Record) []Record {
+	var out []Record
+	for i := range records {
+		if f.Match(&records[i]) {
+			out = append(out, records[i])
+		}
+	}
+	return out
+}
+
+func (f Filter) String() string {
+	parts := make([]string, 0, len(f.terms))
+	for _, t := range f.terms {
+		parts = append(parts, t.key+"="+t.value)
+	}
+	return strings.Join(parts, " ")
+}
+
+func matcher(k, v string) (func(r *Record) bool, error) {
+	if name, ok := strings.CutPrefix(k, "env."); ok {
+		return func(r *Record) bool { return r.Env[name] == v }, nil
+	}
+	switch k {
+	case "exp":
+		return func(r *Record) bool { return r.Experiment == v }, nil
+	case "variant":
+		return func(r *Record) bool { return r.Variant == v }, nil
+	case "build":
+		return func(r *Record) bool { return r.Fingerprint.Experiment == v }, nil
+	case "go":
+		return func(r *Record) bool { return r.Fingerprint.GoVersion == v }, nil
+	case "rev":
+		return func(r *Record) bool { return v != "" && strings.HasPrefix(r.Fingerprint.Revision, v) }, nil
+	case "host":
+		return func(r *Record) bool { return r.Fingerprint.Hostname == v }, nil
+	case "cpu":
+		return func(r *Record) bool { return strings.Contains(r.Fingerprint.CPUModel, v) }, nil
+	case "procs":
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		return func(r *Record) bool { return r.Fingerprint.GOMAXPROCS == n }, nil
+	case "args":
+		return func(r *Record) bool { return strings.Contains(strings.Join(r.Args, " "), v) }, nil
+	case "since", "until":
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		if k == "since" {
+			return func(r *Record) bool { return !r.Time.Before(t) }, nil
+		}
+		return func(r *Record) bool { return r.Time.Before(t) }, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", k)
+}
+
+func parseTime(v string) (time.Time, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
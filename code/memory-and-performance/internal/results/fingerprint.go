@@ -0,0 +1,146 @@
+package results
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Fingerprint identifies the machine and the build a result was measured on.
+// Fields that cannot be read on the current platform are left empty.
+type Fingerprint struct {
+	GoVersion  string `json:"go_version"`
+	Experiment string `json:"goexperiment"` // "std" without GOEXPERIMENT
+	Revision   string `json:"vcs_revision,omitempty"`
+	Modified   bool   `json:"vcs_modified,omitempty"`
+
+	Hostname   string  `json:"hostname"`
+	OS         string  `json:"os"`
+	Arch       string  `json:"arch"`
+	Kernel     string  `json:"kernel,omitempty"`
+	CPUModel   string  `json:"cpu_model,omitempty"`
+	CPUs       int     `json:"cpus"`
+	GOMAXPROCS int     `json:"gomaxprocs"`
+	Caches     []Cache `json:"caches,omitempty"`
+	THP        string  `json:"thp,omitempty"` // transparent huge pages mode, e.g. "madvise"
+}
+
+// Cache is one cache level of cpu0.
+type Cache struct {
+	Level int    `json:"level"`
+	Type  string `json:"type"` // Data, Instruction or Unified
+	Size  string `json:"size"` // as reported by sysfs, e.g. "32K"
+}
+
+// Collect fingerprints the running process.
+func Collect() Fingerprint {
+	fp := Fingerprint{
+		GoVersion:  runtime.Version(),
+		Experiment: "std",
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+	fp.Hostname, _ = os.Hostname()
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fp.GoVersion = info.GoVersion
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "GOEXPERIMENT":
+				if s.Value != "" {
+					fp.Experiment = s.Value
+				}
+			case "vcs.revision":
+				fp.Revision = s.Value
+			case "vcs.modified":
+				fp.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	fp.Kernel = kernel()
+	fp.CPUModel = cpuModel()
+	fp.Caches = caches()
+	fp.THP = thpMode()
+	return fp
+}
+
+// cpuModel reads the first "model name" of /proc/cpuinfo.
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		// arm64 kernels have no model name, only CPU part numbers.
+		switch strings.TrimSpace(k) {
+		case "model name", "Model", "Hardware":
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func caches() []Cache {
+	dirs, _ := filepath.Glob("/sys/devices/system/cpu/cpu0/cache/index*")
+	var out []Cache
+	for _, dir := range dirs {
+		c := Cache{
+			Type: readTrim(filepath.Join(dir, "type")),
+			Size: readTrim(filepath.Join(dir, "size")),
+		}
+		switch readTrim(filepath.Join(dir, "level")) {
+		case "1":
+			c.Level = 1
+		case "2":
+			c.Level = 2
+		case "3":
+			c.Level = 3
+		case "4":
+			c.Level = 4
+		}
+		if c.Size != "" {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Level != out[j].Level {
+			return out[i].Level < out[j].Level
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+// thpMode returns the selected mode of transparent huge pages, the value in
+// brackets of "always [madvise] never".
+func thpMode() string {
+	s := readTrim("/sys/kernel/mm/transparent_hugepage/enabled")
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		if j := strings.IndexByte(s[i:], ']'); j > 0 {
+			return s[i+1 : i+j]
+		}
+	}
+	return s
+}
+
+func readTrim(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,11 @@
+package results
+
+import "syscall"
+
+func kernel() string {
+	v, err := syscall.Sysctl("kern.osrelease")
+	if err != nil {
+		return ""
+	}
+	return "Darwin " + v
+}
@@ -0,0 +1,5 @@
+//go:build !linux && !darwin
+
+package results
+
+func kernel() string { return "" }
@@ -0,0 +1,90 @@
+// Package results is a local, append-only store of experiment runs. Every
+// run is one JSON line holding what was measured and a fingerprint of the
+// machine and build it was measured on, so results from different days,
+// toolchains or hosts can be told apart and compared later.
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPath is the store used when none is given.
+var DefaultPath = filepath.Join("traces", "results.jsonl")
+
+// Record is one run.
+type Record struct {
+	Time       time.Time         `json:"time"`
+	Experiment string            `json:"experiment"` // executable name, e.g. "graphx"
+	Variant    string            `json:"variant,omitempty"`
+	Args       []string          `json:"args"`
+	Env        map[string]string `json:"env,omitempty"` // GC related variables that were set
+
+	Wall   time.Duration `json:"wall_ns"` // measured region
+	User   time.Duration `json:"user_ns"`
+	System time.Duration `json:"system_ns"`
+	MaxRSS int64         `json:"max_rss_bytes"`
+
+	// Activity during the measured region. User, System and MaxRSS above
+	// cover the whole process.
+	GCCycles    uint64        `json:"gc_cycles"`
+	GCPause     time.Duration `json:"gc_pause_ns"`
+	GCCPU       time.Duration `json:"gc_cpu_ns"`
+	HeapAllocs  uint64        `json:"heap_alloc_bytes"`
+	MinorFaults int64         `json:"minor_faults"`
+	MajorFaults int64         `json:"major_faults"`
+
+	Fingerprint Fingerprint `json:"fingerprint"`
+}
+
+// Append writes r as one line at the end of the store at path, creating it
+// and its directory if needed.
+func Append(path string, r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	// A single write per record keeps concurrent appends from interleaving.
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns every record of the store at path in the order they were
+// appended.
+func Read(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, r)
+	}
+	return records, sc.Err()
+}
@@ -0,0 +1,61 @@
+package results
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store", "results.jsonl")
+	now := time.Now()
+	in := []Record{
+		{Time: now.Add(-48 * time.Hour), Experiment: "graph", Variant: "compact", Args: []string{"-v", "compact"}, Wall: time.Second,
+			Fingerprint: Fingerprint{Experiment: "std", Revision: "abc1234"}},
+		{Time: now, Experiment: "graphx", Variant: "compact", Args: []string{"-v", "compact", "-s", "2000000"}, Env: map[string]string{"GOGC": "200"},
+			Fingerprint: Fingerprint{Experiment: "greenteagc", GOMAXPROCS: 8}},
+	}
+	for i := range in {
+		if err := Append(path, &in[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[0].Wall != time.Second || out[1].Env["GOGC"] != "200" {
+		t.Fatalf("round trip: %+v", out)
+	}
+
+	for _, tc := range []struct {
+		terms []string
+		want  int
+	}{
+		{nil, 2},
+		{[]string{"variant=compact"}, 2},
+		{[]string{"build=greenteagc"}, 1},
+		{[]string{"exp=graph", "build=greenteagc"}, 0},
+		{[]string{"rev=abc"}, 1},
+		{[]string{"procs=8"}, 1},
+		{[]string{"args=-s 2000000"}, 1},
+		{[]string{"env.GOGC=200"}, 1},
+		{[]string{"env.GOGC="}, 1},
+		{[]string{"since=24h"}, 1},
+		{[]string{"until=24h"}, 1},
+	} {
+		f, err := ParseFilter(tc.terms...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(f.Apply(out)); got != tc.want {
+			t.Errorf("%v: got %d records, want %d", tc.terms, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"exp", "color=red", "procs=x", "since=yesterday"} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package runner
+
+import "os"
+
+// maxRSS is not supported on this platform, runs record a zero peak RSS.
+func maxRSS(ps *os.ProcessState) int64 { return 0 }
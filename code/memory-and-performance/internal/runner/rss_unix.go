@@ -0,0 +1,21 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+func maxRSS(ps *os.ProcessState) int64 {
+	ru, ok := ps.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	// ru_maxrss is in bytes on macOS and in kilobytes everywhere else.
+	if runtime.GOOS == "darwin" {
+		return int64(ru.Maxrss)
+	}
+	return int64(ru.Maxrss) * 1024
+}
@@ -0,0 +1,141 @@
+// Package runner executes an experiment binary in a fresh process and
+// collects what the runtime reports about it: wall time, rusage and the
+// GODEBUG=gctrace=1 output.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Elvis339/go_gc_eval/internal/gctrace"
+)
+
+// Spec describes a single process run.
+type Spec struct {
+	Path    string
+	Args    []string
+	Env     []string      // added on top of the current environment
+	Dir     string        // working directory, empty for the current one
+	Timeout time.Duration // 0 means no timeout
+	GCTrace bool          // add gctrace=1 to GODEBUG and parse stderr
+
+	// Optional copies of the process output, e.g. files in a results directory.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Result is what was observed about one run.
+type Result struct {
+	Wall     time.Duration
+	User     time.Duration
+	System   time.Duration
+	MaxRSS   int64 // peak resident set size in bytes
+	ExitCode int   // -1 if the process never started or was killed
+	Stdout   []byte
+	Stderr   []byte
+	GC       []gctrace.Record
+}
+
+// GCSummary summarizes the gctrace records of the run.
+func (r *Result) GCSummary() gctrace.Summary {
+	return gctrace.Summarize(r.GC)
+}
+
+// ErrTimeout is returned when a run exceeds Spec.Timeout.
+var ErrTimeout = errors.New("run timed out")
+
+// Run starts the process described by spec and waits for it. A process that
+// exits with a non-zero status returns both the Result and an error.
+func Run(ctx context.Context, spec Spec) (*Result, error) {
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, spec.Path, spec.Args...)
+	cmd.Dir = spec.Dir
+	cmd.Env = Environ(os.Environ(), spec.Env, spec.GCTrace)
+	cmd.Stdout = tee(&stdout, spec.Stdout)
+	cmd.Stderr = tee(&stderr, spec.Stderr)
+
+	start := time.Now()
+	err := cmd.Run()
+	wall := time.Since(start)
+
+	res := &Result{
+		Wall:     wall,
+		ExitCode: -1,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}
+	if ps := cmd.ProcessState; ps != nil {
+		res.ExitCode = ps.ExitCode()
+		res.User = ps.UserTime()
+		res.System = ps.SystemTime()
+		res.MaxRSS = maxRSS(ps)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return res, fmt.Errorf("%s: %w after %s", spec.Path, ErrTimeout, spec.Timeout)
+	}
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", spec.Path, err)
+	}
+
+	if spec.GCTrace {
+		res.GC, err = gctrace.Parse(bytes.NewReader(res.Stderr))
+		if err != nil {
+			return res, fmt.Errorf("%s: parse gctrace: %w", spec.Path, err)
+		}
+	}
+	return res, nil
+}
+
+// Environ returns base with extra applied on top. GODEBUG settings are merged
+// rather than replaced so knobs from base and extra combine; gctrace adds
+// gctrace=1.
+func Environ(base, extra []string, gctrace bool) []string {
+	env := make(map[string]string)
+	var order []string
+	set := func(kv string) {
+		k, v, _ := strings.Cut(kv, "=")
+		if _, ok := env[k]; !ok {
+			order = append(order, k)
+		}
+		if k == "GODEBUG" && env[k] != "" && v != "" {
+			v = env[k] + "," + v
+		}
+		env[k] = v
+	}
+	for _, kv := range base {
+		set(kv)
+	}
+	for _, kv := range extra {
+		set(kv)
+	}
+	if gctrace {
+		set("GODEBUG=gctrace=1")
+	}
+
+	out := make([]string, 0, len(order))
+	for _, k := range order {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
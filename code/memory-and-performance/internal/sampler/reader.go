@@ -0,0 +1,96 @@
+package sampler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ReadFile loads a recorded time series, the format is picked from the
+// extension like Create does. CSV files only carry the flattened histogram
+// columns, which end up in Sample.Values.
+func ReadFile(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	switch ext := filepath.Ext(path); ext {
+	case ".jsonl":
+		samples, err = readJSONL(f)
+	case ".csv":
+		samples, err = readCSV(f)
+	default:
+		return nil, fmt.Errorf("unsupported metrics format %q, use .csv or .jsonl", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
+
+func readJSONL(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+func readCSV(r io.Reader) ([]Sample, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	if len(header) < 2 || header[0] != "time" || header[1] != "elapsed_s" {
+		return nil, fmt.Errorf("unexpected header %v", header)
+	}
+
+	samples := make([]Sample, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		t, err := time.Parse(time.RFC3339Nano, row[0])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		elapsed, err := strconv.ParseFloat(row[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		s := Sample{
+			Time:    t,
+			Elapsed: time.Duration(elapsed * float64(time.Second)),
+			Values:  make(map[string]float64, len(header)-2),
+		}
+		for j := 2; j < len(header) && j < len(row); j++ {
+			v, err := strconv.ParseFloat(row[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, %s: %w", i+2, header[j], err)
+			}
+			s.Values[header[j]] = v
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
@@ -0,0 +1,25 @@
+package sampler
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// ReadRSS returns the resident set size in bytes from /proc/self/statm. ok
+// is false where there is no procfs.
+func ReadRSS() (rss uint64, ok bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
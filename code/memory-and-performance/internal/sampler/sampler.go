@@ -0,0 +1,252 @@
+// Package sampler records runtime/metrics as a time series so what statsviz
+// shows live can be saved next to the pprof outputs and replayed later.
+package sampler
+
+import (
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// Scalars are the uint64/float64 metrics recorded on every tick. Names the
+// running toolchain does not know are skipped.
+var Scalars = []string{
+	// heap classes
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/heap/unused:bytes",
+	"/memory/classes/heap/free:bytes",
+	"/memory/classes/heap/released:bytes",
+	"/memory/classes/heap/stacks:bytes",
+	"/memory/classes/total:bytes",
+	"/gc/heap/live:bytes",
+	"/gc/heap/goal:bytes",
+	"/gc/heap/objects:objects",
+	"/gc/heap/allocs:bytes",
+	"/gc/scan/heap:bytes",
+
+	// GC cycles
+	"/gc/cycles/total:gc-cycles",
+	"/gc/cycles/forced:gc-cycles",
+
+	// GC CPU classes
+	"/cpu/classes/gc/mark/assist:cpu-seconds",
+	"/cpu/classes/gc/mark/dedicated:cpu-seconds",
+	"/cpu/classes/gc/mark/idle:cpu-seconds",
+	"/cpu/classes/gc/pause:cpu-seconds",
+	"/cpu/classes/gc/total:cpu-seconds",
+	"/cpu/classes/total:cpu-seconds",
+
+	// scheduler
+	"/sched/goroutines:goroutines",
+	"/sched/gomaxprocs:threads",
+}
+
+// RSS is the resident set size of the process. It is not a runtime/metrics
+// name but is recorded like one, where the OS reports it, so heap in-use can
+// be compared with what the process actually holds.
+const RSS = "/process/rss:bytes"
+
+// Histograms are the distributions recorded on every tick. The first known
+// name of each group is used, /gc/pauses:seconds is the pre Go 1.22 name.
+var Histograms = [][]string{
+	{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"},
+	{"/sched/latencies:seconds"},
+}
+
+// Bucket is a non-empty histogram bucket covering [Lo, Hi). Infinite bounds
+// are clamped to ±math.MaxFloat64 so they survive JSON encoding.
+type Bucket struct {
+	Lo    float64 `json:"lo"`
+	Hi    float64 `json:"hi"`
+	Count uint64  `json:"n"`
+}
+
+// Histogram is a cumulative runtime/metrics histogram, only non-empty buckets are kept.
+type Histogram []Bucket
+
+// Count returns the number of observations.
+func (h Histogram) Count() uint64 {
+	var n uint64
+	for _, b := range h {
+		n += b.Count
+	}
+	return n
+}
+
+// Quantile returns the upper bound of the bucket holding the q-th quantile.
+func (h Histogram) Quantile(q float64) float64 {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for _, b := range h {
+		seen += b.Count
+		if seen >= target {
+			return b.Hi
+		}
+	}
+	return h[len(h)-1].Hi
+}
+
+// Sample is the state of the runtime at one point in time.
+type Sample struct {
+	Time       time.Time            `json:"time"`
+	Elapsed    time.Duration        `json:"elapsed"`
+	Values     map[string]float64   `json:"values"`
+	Histograms map[string]Histogram `json:"histograms,omitempty"`
+}
+
+// Writer persists samples.
+type Writer interface {
+	Write(s *Sample) error
+	Close() error
+}
+
+// Sampler polls runtime/metrics at a fixed interval until stopped.
+type Sampler struct {
+	interval time.Duration
+	w        Writer
+	samples  []metrics.Sample
+	start    time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	err  error
+}
+
+// New returns a sampler that writes to w every interval.
+func New(interval time.Duration, w Writer) *Sampler {
+	return &Sampler{
+		interval: interval,
+		w:        w,
+		samples:  descriptors(),
+		done:     make(chan struct{}),
+	}
+}
+
+// Names returns the scalar and histogram names this toolchain supports, in
+// the order they are recorded.
+func Names() (scalars, histograms []string) {
+	known := make(map[string]bool)
+	for _, d := range metrics.All() {
+		known[d.Name] = true
+	}
+	for _, name := range Scalars {
+		if known[name] {
+			scalars = append(scalars, name)
+		}
+	}
+	if _, ok := ReadRSS(); ok {
+		scalars = append(scalars, RSS)
+	}
+	for _, group := range Histograms {
+		for _, name := range group {
+			if known[name] {
+				histograms = append(histograms, name)
+				break
+			}
+		}
+	}
+	return scalars, histograms
+}
+
+func descriptors() []metrics.Sample {
+	scalars, histograms := Names()
+	samples := make([]metrics.Sample, 0, len(scalars)+len(histograms))
+	for _, name := range append(scalars, histograms...) {
+		if name == RSS {
+			continue
+		}
+		samples = append(samples, metrics.Sample{Name: name})
+	}
+	return samples
+}
+
+// Start takes the first sample and starts polling in the background.
+func (s *Sampler) Start() {
+	s.start = time.Now()
+	s.record()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.record()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop takes a final sample, stops polling and closes the writer.
+func (s *Sampler) Stop() error {
+	close(s.done)
+	s.wg.Wait()
+	s.record()
+
+	if err := s.w.Close(); err != nil && s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
+
+func (s *Sampler) record() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+
+	metrics.Read(s.samples)
+	now := time.Now()
+	sample := &Sample{
+		Time:       now,
+		Elapsed:    now.Sub(s.start),
+		Values:     make(map[string]float64, len(s.samples)),
+		Histograms: make(map[string]Histogram),
+	}
+	for _, m := range s.samples {
+		switch m.Value.Kind() {
+		case metrics.KindUint64:
+			sample.Values[m.Name] = float64(m.Value.Uint64())
+		case metrics.KindFloat64:
+			sample.Values[m.Name] = m.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			sample.Histograms[m.Name] = compact(m.Value.Float64Histogram())
+		}
+	}
+	if rss, ok := ReadRSS(); ok {
+		sample.Values[RSS] = float64(rss)
+	}
+	s.err = s.w.Write(sample)
+}
+
+func compact(h *metrics.Float64Histogram) Histogram {
+	var out Histogram
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		out = append(out, Bucket{Lo: clamp(h.Buckets[i]), Hi: clamp(h.Buckets[i+1]), Count: n})
+	}
+	return out
+}
+
+func clamp(v float64) float64 {
+	switch {
+	case math.IsInf(v, 1):
+		return math.MaxFloat64
+	case math.IsInf(v, -1):
+		return -math.MaxFloat64
+	}
+	return v
+}
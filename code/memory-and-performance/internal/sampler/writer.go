@@ -0,0 +1,119 @@
+package sampler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Create opens path for writing and picks the format from its extension:
+// ".csv" or ".jsonl".
+func Create(path string) (Writer, error) {
+	ext := filepath.Ext(path)
+	if ext != ".csv" && ext != ".jsonl" {
+		return nil, fmt.Errorf("unsupported metrics format %q, use .csv or .jsonl", ext)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".csv" {
+		return newCSVWriter(f), nil
+	}
+	return newJSONLWriter(f), nil
+}
+
+// jsonlWriter writes one JSON encoded Sample per line, histograms included.
+type jsonlWriter struct {
+	f   *os.File
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(f *os.File) *jsonlWriter {
+	buf := bufio.NewWriter(f)
+	return &jsonlWriter{f: f, buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (w *jsonlWriter) Write(s *Sample) error {
+	return w.enc.Encode(s)
+}
+
+func (w *jsonlWriter) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// HistogramColumns are the values a histogram is flattened to in CSV.
+var HistogramColumns = []struct {
+	Suffix string
+	Value  func(h Histogram) float64
+}{
+	{":count", func(h Histogram) float64 { return float64(h.Count()) }},
+	{":p50", func(h Histogram) float64 { return h.Quantile(0.50) }},
+	{":p99", func(h Histogram) float64 { return h.Quantile(0.99) }},
+	{":max", func(h Histogram) float64 { return h.Quantile(1) }},
+}
+
+// csvWriter writes one row per Sample. The columns are fixed by the metrics
+// the toolchain supports; histograms are flattened to HistogramColumns.
+type csvWriter struct {
+	f          *os.File
+	w          *csv.Writer
+	scalars    []string
+	histograms []string
+	header     bool
+}
+
+func newCSVWriter(f *os.File) *csvWriter {
+	scalars, histograms := Names()
+	return &csvWriter{f: f, w: csv.NewWriter(f), scalars: scalars, histograms: histograms}
+}
+
+func (w *csvWriter) Write(s *Sample) error {
+	if !w.header {
+		header := append([]string{"time", "elapsed_s"}, w.scalars...)
+		for _, name := range w.histograms {
+			for _, c := range HistogramColumns {
+				header = append(header, name+c.Suffix)
+			}
+		}
+		if err := w.w.Write(header); err != nil {
+			return err
+		}
+		w.header = true
+	}
+
+	row := []string{s.Time.Format(time.RFC3339Nano), formatFloat(s.Elapsed.Seconds())}
+	for _, name := range w.scalars {
+		row = append(row, formatFloat(s.Values[name]))
+	}
+	for _, name := range w.histograms {
+		for _, c := range HistogramColumns {
+			row = append(row, formatFloat(c.Value(s.Histograms[name])))
+		}
+	}
+	return w.w.Write(row)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,237 @@
+// Package stats has the small amount of statistics needed to compare
+// repeated runs: medians with distribution-free confidence intervals and the
+// Mann-Whitney U test, the same approach benchstat uses.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Sample is a set of measurements of one metric.
+type Sample []float64
+
+func (s Sample) sorted() []float64 {
+	c := append([]float64(nil), s...)
+	sort.Float64s(c)
+	return c
+}
+
+// Mean returns the arithmetic mean, NaN for an empty sample.
+func (s Sample) Mean() float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range s {
+		sum += v
+	}
+	return sum / float64(len(s))
+}
+
+// StdDev returns the sample standard deviation.
+func (s Sample) StdDev() float64 {
+	if len(s) < 2 {
+		return 0
+	}
+	m := s.Mean()
+	sum := 0.0
+	for _, v := range s {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(s)-1))
+}
+
+// Max returns the largest value, NaN for an empty sample.
+func (s Sample) Max() float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	m := s[0]
+	for _, v := range s[1:] {
+		m = math.Max(m, v)
+	}
+	return m
+}
+
+// Min returns the smallest value, NaN for an empty sample.
+func (s Sample) Min() float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	m := s[0]
+	for _, v := range s[1:] {
+		m = math.Min(m, v)
+	}
+	return m
+}
+
+// Median returns the median, NaN for an empty sample.
+func (s Sample) Median() float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	c := s.sorted()
+	n := len(c)
+	if n%2 == 1 {
+		return c[n/2]
+	}
+	return (c[n/2-1] + c[n/2]) / 2
+}
+
+// MedianCI returns a distribution-free confidence interval for the median
+// at the given confidence level (e.g. 0.95), built from order statistics.
+// Small samples cannot reach every level; the widest interval, min to max,
+// is returned in that case together with the confidence it actually has.
+func (s Sample) MedianCI(confidence float64) (lo, hi, actual float64) {
+	n := len(s)
+	if n == 0 {
+		return math.NaN(), math.NaN(), 0
+	}
+	c := s.sorted()
+
+	// The number of observations below the median is Binomial(n, 1/2).
+	// Pick the largest k for which [c[k], c[n-1-k]] still has the requested coverage.
+	k := 0
+	actual = coverage(n, 0)
+	for j := 1; j < n/2; j++ {
+		cov := coverage(n, j)
+		if cov < confidence {
+			break
+		}
+		k, actual = j, cov
+	}
+	return c[k], c[n-1-k], actual
+}
+
+// coverage is P(k < X < n-k) for X ~ Binomial(n, 1/2): the probability the
+// interval between the (k+1)-th and (n-k)-th order statistic contains the median.
+func coverage(n, k int) float64 {
+	p := 0.0
+	for i := k + 1; i <= n-k-1; i++ {
+		p += binomPMF(n, i)
+	}
+	return p
+}
+
+func binomPMF(n, k int) float64 {
+	lg := func(x int) float64 {
+		v, _ := math.Lgamma(float64(x + 1))
+		return v
+	}
+	return math.Exp(lg(n) - lg(k) - lg(n-k) - float64(n)*math.Ln2)
+}
+
+// MannWhitneyU returns the two-sided p-value of the Mann-Whitney U test for
+// the null hypothesis that a and b come from the same distribution. Small
+// samples without ties use the exact distribution of U, otherwise the normal
+// approximation with tie and continuity correction is used.
+func MannWhitneyU(a, b Sample) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return math.NaN()
+	}
+
+	type obs struct {
+		v     float64
+		first bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Rank with midranks for ties and keep the tie correction term.
+	var r1, tieTerm float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				r1 += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+
+	u1 := r1 - float64(n1*(n1+1))/2
+	u := math.Min(u1, float64(n1*n2)-u1)
+
+	if !ties && n1+n2 <= 50 {
+		p := 2 * exactUCDF(n1, n2, int(u))
+		return math.Min(p, 1)
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (math.Abs(u-mu) - 0.5) / sigma
+	if z < 0 {
+		z = 0
+	}
+	return math.Min(math.Erfc(z/math.Sqrt2), 1)
+}
+
+// MannWhitneyUMinP returns the smallest p-value MannWhitneyU can return for
+// samples of n1 and n2 values without ties, 2/C(n1+n2, n1) when they do not
+// overlap at all. A test at level alpha cannot reject anything unless this is
+// below alpha: 3 runs a side never get under 0.1.
+func MannWhitneyUMinP(n1, n2 int) float64 {
+	lg := func(x int) float64 {
+		v, _ := math.Lgamma(float64(x + 1))
+		return v
+	}
+	return math.Min(1, 2*math.Exp(lg(n1)+lg(n2)-lg(n1+n2)))
+}
+
+// exactUCDF returns P(U <= u) for sample sizes n1 and n2 without ties.
+func exactUCDF(n1, n2, u int) float64 {
+	// counts[i][j][k]: number of orderings of i and j observations with U == k,
+	// computed one row at a time with f(i, j, k) = f(i-1, j, k-j) + f(i, j-1, k).
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				v := cur[j-1][k]
+				if k >= j {
+					v += prev[j][k-j]
+				}
+				cur[j][k] = v
+			}
+		}
+		prev = cur
+	}
+
+	total, below := 0.0, 0.0
+	for k, c := range prev[n2] {
+		total += c
+		if k <= u {
+			below += c
+		}
+	}
+	return below / total
+}
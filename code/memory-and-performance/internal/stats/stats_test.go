@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestMedian(t *testing.T) {
+	if m := (Sample{3, 1, 2}).Median(); m != 2 {
+		t.Errorf("odd median %v", m)
+	}
+	if m := (Sample{4, 1, 3, 2}).Median(); m != 2.5 {
+		t.Errorf("even median %v", m)
+	}
+	if m := (Sample{}).Median(); !math.IsNaN(m) {
+		t.Errorf("empty median %v", m)
+	}
+}
+
+func TestMedianCI(t *testing.T) {
+	s := Sample{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}
+	lo, hi, actual := s.MedianCI(0.95)
+	// For n=10 the 95% interval is between the 2nd and 9th order statistic.
+	if lo != 2 || hi != 9 {
+		t.Errorf("got [%v, %v]", lo, hi)
+	}
+	if !near(actual, 1-2*11.0/1024) {
+		t.Errorf("actual confidence %v", actual)
+	}
+
+	// Three runs can't give 95%, the full range is returned.
+	lo, hi, actual = (Sample{1, 2, 3}).MedianCI(0.95)
+	if lo != 1 || hi != 3 || !near(actual, 0.75) {
+		t.Errorf("got [%v, %v] at %v", lo, hi, actual)
+	}
+}
+
+func TestMannWhitneyUExact(t *testing.T) {
+	// R: wilcox.test(1:3, 4:6)$p.value == 0.1
+	if p := MannWhitneyU(Sample{1, 2, 3}, Sample{4, 5, 6}); !near(p, 0.1) {
+		t.Errorf("p=%v", p)
+	}
+
+	var a, b Sample
+	for i := 1; i <= 10; i++ {
+		a = append(a, float64(i))
+		b = append(b, float64(i+10))
+	}
+	// Only 2 of the C(20, 10) orderings are as extreme.
+	if p := MannWhitneyU(a, b); !near(p, 2.0/184756) {
+		t.Errorf("p=%v", p)
+	}
+	if p := MannWhitneyU(b, a); !near(p, 2.0/184756) {
+		t.Errorf("p not symmetric: %v", p)
+	}
+}
+
+func TestMannWhitneyUMinP(t *testing.T) {
+	for _, n := range []int{3, 4, 10} {
+		var a, b Sample
+		for i := 1; i <= n; i++ {
+			a = append(a, float64(i))
+			b = append(b, float64(i+n))
+		}
+		if got, want := MannWhitneyUMinP(n, n), MannWhitneyU(a, b); !near(got, want) {
+			t.Errorf("n=%d: min p %v, separated samples p=%v", n, got, want)
+		}
+	}
+	if p := MannWhitneyUMinP(4, 4); p > 0.05 || p < 0.028 {
+		t.Errorf("n=4: min p %v", p)
+	}
+}
+
+func TestMannWhitneyUTies(t *testing.T) {
+	same := Sample{5, 5, 5, 5, 5}
+	if p := MannWhitneyU(same, same); p != 1 {
+		t.Errorf("identical samples p=%v", p)
+	}
+
+	a := Sample{1, 1, 2, 2, 3, 3, 4, 4}
+	b := Sample{5, 5, 6, 6, 7, 7, 8, 8}
+	if p := MannWhitneyU(a, b); p > 0.01 {
+		t.Errorf("separated samples with ties p=%v", p)
+	}
+}